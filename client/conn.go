@@ -0,0 +1,419 @@
+// Package client implements a 9P client.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+// ErrClosed is returned for requests on a closed connection.
+var ErrClosed = errors.New("9p: connection closed")
+
+// errTags is returned when every tag is in use.
+var errTags = errors.New("9p: out of tags")
+
+// Error is an error returned by the server.
+type Error struct {
+	Ename string // the error string of Rerror
+	Errno uint32 // the errno of a 9P2000.u Rerror or 9P2000.L Rlerror, or 0
+}
+
+func (e *Error) Error() string { return e.Ename }
+
+// Is reports whether e is one of fs.ErrNotExist, fs.ErrExist or
+// fs.ErrPermission, going by the errno or the error strings of Plan 9
+// and Unix servers.
+func (e *Error) Is(target error) bool {
+	var errno syscall.Errno
+	var enames []string
+	switch target {
+	case fs.ErrNotExist:
+		errno, enames = syscall.ENOENT, []string{"file does not exist", "no such file or directory"}
+	case fs.ErrExist:
+		errno, enames = syscall.EEXIST, []string{"file already exists", "file exists"}
+	case fs.ErrPermission:
+		errno, enames = syscall.EACCES, []string{"permission denied"}
+	default:
+		return false
+	}
+	if e.Errno != 0 {
+		return syscall.Errno(e.Errno) == errno
+	}
+	ename := strings.ToLower(e.Ename)
+	for _, s := range enames {
+		if strings.Contains(ename, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a 9P connection. Its methods may be called from multiple
+// goroutines, requests are multiplexed over the connection and replies
+// are matched to them by tag.
+type Conn struct {
+	rwc     io.ReadWriteCloser
+	dec     *proto.Decoder
+	session plan9.Session
+
+	wmu sync.Mutex // guards enc
+	enc *proto.Encoder
+
+	mu       sync.Mutex // guards the fields below
+	free     []plan9.Tag
+	next     plan9.Tag
+	pending  map[plan9.Tag]chan proto.Message
+	flushing map[plan9.Tag]bool // tags kept until their Rflush
+	fids     []plan9.FID
+	nextFid  plan9.FID
+	err      error
+
+	done chan struct{} // closed when the connection fails
+}
+
+// Dial connects to the 9P server at addr on the named network.
+func Dial(ctx context.Context, network, addr string) (*Conn, error) {
+	var d net.Dialer
+	c, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(ctx, c, plan9.MSize, plan9.DefaultVersion)
+}
+
+// NewConn starts a 9P session on rwc, offering msize and version in
+// Tversion. If the session can't be started rwc is closed.
+func NewConn(ctx context.Context, rwc io.ReadWriteCloser, msize uint32, version string) (*Conn, error) {
+	c := &Conn{
+		rwc:      rwc,
+		dec:      proto.NewDecoder(rwc),
+		enc:      proto.NewEncoder(rwc),
+		pending:  make(map[plan9.Tag]chan proto.Message),
+		flushing: make(map[plan9.Tag]bool),
+		done:     make(chan struct{}),
+	}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			rwc.Close()
+		case <-stop:
+		}
+	}()
+	s, err := proto.Version(c.enc, c.dec, msize, version)
+	close(stop)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		rwc.Close()
+		return nil, err
+	}
+	c.session = s
+	go c.read()
+	return c, nil
+}
+
+// Session returns the session agreed on with the server.
+func (c *Conn) Session() plan9.Session { return c.session }
+
+// Close closes the connection. Outstanding requests fail with ErrClosed.
+func (c *Conn) Close() error {
+	err := c.rwc.Close()
+	c.fail(ErrClosed)
+	return err
+}
+
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// read delivers replies to the requests waiting for them.
+func (c *Conn) read() {
+	for {
+		msg, err := c.dec.Decode()
+		if err != nil {
+			c.rwc.Close()
+			if err == io.EOF {
+				err = ErrClosed
+			}
+			c.fail(err)
+			return
+		}
+		tag := msg.Header().Tag()
+		c.mu.Lock()
+		if ch, ok := c.pending[tag]; ok {
+			if !c.flushing[tag] {
+				delete(c.pending, tag)
+				c.free = append(c.free, tag)
+			}
+			select {
+			case ch <- msg:
+			default: // a second reply to a flushed tag
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// newTag allocates a tag and registers a request for it.
+func (c *Conn) newTag() (plan9.Tag, chan proto.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, nil, c.err
+	}
+	var tag plan9.Tag
+	switch {
+	case len(c.free) > 0:
+		tag = c.free[len(c.free)-1]
+		c.free = c.free[:len(c.free)-1]
+	case c.next < plan9.NoTag:
+		tag = c.next
+		c.next++
+	default:
+		return 0, nil, errTags
+	}
+	ch := make(chan proto.Message, 1)
+	c.pending[tag] = ch
+	return tag, ch, nil
+}
+
+// releaseTag returns the tag of a request that was never sent.
+func (c *Conn) releaseTag(tag plan9.Tag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, tag)
+	c.free = append(c.free, tag)
+}
+
+// flush sends a Tflush for the request with oldtag, waiting for its reply
+// on ch, and waits for the Rflush. Until then oldtag is kept from reuse.
+// The reply to oldtag is returned if it arrived first, as flush(5)
+// requires the client to honor it, or else nil.
+func (c *Conn) flush(oldtag plan9.Tag, ch chan proto.Message) proto.Message {
+	c.mu.Lock()
+	if c.pending[oldtag] != ch {
+		// answered before it could be flushed
+		c.mu.Unlock()
+		return <-ch
+	}
+	c.flushing[oldtag] = true
+	c.mu.Unlock()
+
+	tag, fch, err := c.newTag()
+	if err == nil {
+		c.wmu.Lock()
+		err = c.enc.Tflush(tag, oldtag)
+		if err == nil {
+			err = c.enc.Flush()
+		}
+		c.wmu.Unlock()
+		if err != nil {
+			c.releaseTag(tag)
+		}
+	}
+	if err == nil {
+		select {
+		case <-fch:
+		case <-c.done:
+			return nil
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.flushing, oldtag)
+	select {
+	case msg := <-ch:
+		delete(c.pending, oldtag)
+		c.free = append(c.free, oldtag)
+		return msg
+	default:
+		if err == nil {
+			delete(c.pending, oldtag)
+			c.free = append(c.free, oldtag)
+		}
+		// else read releases oldtag when the reply arrives
+		return nil
+	}
+}
+
+// rpc writes a request of type t with send and waits for its reply.
+// Rerror and Rlerror are returned as *Error. If ctx is done first, the
+// request is flushed and ctx.Err() returned once the server has forgotten
+// it, unless its reply arrives before the Rflush.
+func (c *Conn) rpc(ctx context.Context, t plan9.MessageType, send func(enc *proto.Encoder, tag plan9.Tag) error) (proto.Message, error) {
+	tag, ch, err := c.newTag()
+	if err != nil {
+		return nil, err
+	}
+	c.wmu.Lock()
+	err = send(c.enc, tag)
+	if err == nil {
+		err = c.enc.Flush()
+	}
+	c.wmu.Unlock()
+	if err != nil {
+		c.releaseTag(tag)
+		return nil, err
+	}
+
+	var msg proto.Message
+	select {
+	case msg = <-ch:
+	case <-ctx.Done():
+		if msg = c.flush(tag, ch); msg == nil {
+			return nil, ctx.Err()
+		}
+	case <-c.done:
+		return nil, c.err
+	}
+
+	switch r := msg.(type) {
+	case *proto.ErrorResp:
+		return nil, &Error{Ename: r.Ename(), Errno: r.Errno()}
+	case *proto.LerrorResp:
+		return nil, &Error{Ename: syscall.Errno(r.Ecode()).Error(), Errno: r.Ecode()}
+	}
+	if msg.Header().Type() != t.Response() {
+		return nil, fmt.Errorf("9p: unexpected %v in reply to %v", msg.Header().Type(), t)
+	}
+	return msg, nil
+}
+
+// Auth starts authentication as uname on the file tree aname, with afid as
+// the authentication fid.
+func (c *Conn) Auth(ctx context.Context, afid plan9.FID, uname, aname string) (plan9.QID, error) {
+	msg, err := c.rpc(ctx, plan9.Tauth, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tauth(tag, afid, uname, aname, plan9.NoUID)
+	})
+	if err != nil {
+		return plan9.QID{}, err
+	}
+	return msg.(*proto.AuthResp).Aqid(), nil
+}
+
+// Attach attaches fid to the root of the file tree aname as uname.
+// afid is the authentication fid, or plan9.NoFID.
+func (c *Conn) Attach(ctx context.Context, fid, afid plan9.FID, uname, aname string) (plan9.QID, error) {
+	msg, err := c.rpc(ctx, plan9.Tattach, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tattach(tag, fid, afid, uname, aname, plan9.NoUID)
+	})
+	if err != nil {
+		return plan9.QID{}, err
+	}
+	return msg.(*proto.AttachResp).Qid(), nil
+}
+
+// Walk walks newfid to the file reached from fid through wname. It returns
+// the qids of the elements walked, which are fewer than wname if the walk
+// failed part way, in which case newfid is not affected.
+func (c *Conn) Walk(ctx context.Context, fid, newfid plan9.FID, wname ...string) ([]plan9.QID, error) {
+	msg, err := c.rpc(ctx, plan9.Twalk, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Twalk(tag, fid, newfid, wname)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*proto.WalkResp).Wqid(), nil
+}
+
+// Open opens fid with mode, returning the qid and iounit of the file.
+func (c *Conn) Open(ctx context.Context, fid plan9.FID, mode uint8) (plan9.QID, uint32, error) {
+	msg, err := c.rpc(ctx, plan9.Topen, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Topen(tag, fid, mode)
+	})
+	if err != nil {
+		return plan9.QID{}, 0, err
+	}
+	r := msg.(*proto.OpenResp)
+	return r.Qid(), r.Iounit(), nil
+}
+
+// Create creates name in the directory fid and opens it with mode, after
+// which fid represents the new file. It returns the qid and iounit of the file.
+func (c *Conn) Create(ctx context.Context, fid plan9.FID, name string, perm plan9.Perm, mode uint8) (plan9.QID, uint32, error) {
+	msg, err := c.rpc(ctx, plan9.Tcreate, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tcreate(tag, fid, name, uint32(perm), mode, "")
+	})
+	if err != nil {
+		return plan9.QID{}, 0, err
+	}
+	r := msg.(*proto.CreateResp)
+	return r.Qid(), r.Iounit(), nil
+}
+
+// Read reads up to count bytes at offset from fid.
+func (c *Conn) Read(ctx context.Context, fid plan9.FID, offset uint64, count uint32) ([]byte, error) {
+	msg, err := c.rpc(ctx, plan9.Tread, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tread(tag, fid, offset, count)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*proto.ReadResp).Data(), nil
+}
+
+// Write writes data at offset to fid, returning the number of bytes written.
+func (c *Conn) Write(ctx context.Context, fid plan9.FID, offset uint64, data []byte) (uint32, error) {
+	msg, err := c.rpc(ctx, plan9.Twrite, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Twrite(tag, fid, offset, data)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return msg.(*proto.WriteResp).Count(), nil
+}
+
+// Clunk forgets fid.
+func (c *Conn) Clunk(ctx context.Context, fid plan9.FID) error {
+	_, err := c.rpc(ctx, plan9.Tclunk, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tclunk(tag, fid)
+	})
+	return err
+}
+
+// Remove removes the file fid represents and clunks fid, even if the
+// remove fails.
+func (c *Conn) Remove(ctx context.Context, fid plan9.FID) error {
+	_, err := c.rpc(ctx, plan9.Tremove, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tremove(tag, fid)
+	})
+	return err
+}
+
+// Stat returns the directory entry of fid.
+func (c *Conn) Stat(ctx context.Context, fid plan9.FID) (*plan9.Dir, error) {
+	msg, err := c.rpc(ctx, plan9.Tstat, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Tstat(tag, fid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*proto.StatResp).Stat(), nil
+}
+
+// Wstat changes the directory entry of fid to d.
+func (c *Conn) Wstat(ctx context.Context, fid plan9.FID, d *plan9.Dir) error {
+	_, err := c.rpc(ctx, plan9.Twstat, func(enc *proto.Encoder, tag plan9.Tag) error {
+		return enc.Twstat(tag, fid, d)
+	})
+	return err
+}
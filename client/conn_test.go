@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+var testQID = plan9.QID{Path: 1, Type: plan9.QTDIR}
+
+// testServer answers Tversion and hands every other request to handle,
+// which runs in a goroutine per request so replies go out in any order.
+func testServer(t *testing.T, handle func(enc *proto.Encoder, msg proto.Message)) *Conn {
+	c, s := net.Pipe()
+	go func() {
+		defer s.Close()
+		dec, enc := proto.NewDecoder(s), proto.NewEncoder(s)
+		var mu sync.Mutex
+		for {
+			msg, err := dec.Decode()
+			if err != nil {
+				return
+			}
+			if v, ok := msg.(*proto.VersionReq); ok {
+				session, _ := plan9.Negotiate(v.Msize(), v.Version(), 8192)
+				enc.Rversion(plan9.NoTag, session.Msize, session.Version)
+				enc.Flush()
+				continue
+			}
+			if msg.Header().Tag() == plan9.NoTag {
+				t.Errorf("%v sent with NoTag", msg.Header().Type())
+			}
+			go func() {
+				mu.Lock()
+				defer mu.Unlock()
+				handle(enc, msg)
+				enc.Flush()
+			}()
+		}
+	}()
+	conn, err := NewConn(context.Background(), c, plan9.MSize, plan9.DefaultVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestConn(t *testing.T) {
+	ctx := context.Background()
+	conn := testServer(t, func(enc *proto.Encoder, msg proto.Message) {
+		tag := msg.Header().Tag()
+		switch m := msg.(type) {
+		case *proto.AttachReq:
+			enc.Rattach(tag, testQID)
+		case *proto.WalkReq:
+			if len(m.Wname()) > 0 && m.Wname()[0] == "glenda" {
+				enc.Rwalk(tag, []plan9.QID{testQID})
+				return
+			}
+			enc.Rerror(tag, "file does not exist", 0)
+		case *proto.OpenReq:
+			enc.Ropen(tag, testQID, 8168)
+		case *proto.ReadReq:
+			enc.Rread(tag, []byte("hello"))
+		case *proto.StatReq:
+			enc.Rstat(tag, &plan9.Dir{Name: "glenda", QID: testQID})
+		case *proto.ClunkReq:
+			enc.Rstat(tag, &plan9.Dir{})
+		}
+	})
+	defer conn.Close()
+	assert.Equal(t, conn.Session().Msize, uint32(8192))
+
+	qid, err := conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.NilError(t, err)
+	assert.Equal(t, qid, testQID)
+
+	qids, err := conn.Walk(ctx, 1, 2, "glenda")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, qids, []plan9.QID{testQID})
+
+	_, err = conn.Walk(ctx, 1, 3, "sys")
+	assert.DeepEqual(t, err, &Error{Ename: "file does not exist"})
+
+	_, iounit, err := conn.Open(ctx, 2, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, iounit, uint32(8168))
+
+	data, err := conn.Read(ctx, 2, 0, iounit)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), "hello")
+
+	d, err := conn.Stat(ctx, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Name, "glenda")
+
+	// a reply of the wrong type
+	if err := conn.Clunk(ctx, 2); err == nil {
+		t.Fatal("Clunk answered with Rstat succeeded")
+	}
+}
+
+func TestConnConcurrent(t *testing.T) {
+	conn := testServer(t, func(enc *proto.Encoder, msg proto.Message) {
+		// reply with the offset, handlers run in any order
+		r := msg.(*proto.ReadReq)
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, r.Offset())
+		enc.Rread(r.Header().Tag(), b)
+	})
+	defer conn.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				off := uint64(i*10 + j)
+				data, err := conn.Read(context.Background(), 1, off, 8)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got := binary.LittleEndian.Uint64(data); got != off {
+					t.Errorf("Read at %d got reply for %d", off, got)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConnCancel(t *testing.T) {
+	tags := make(chan plan9.Tag, 1)
+	flushed := make(chan plan9.Tag, 1)
+	conn := testServer(t, func(enc *proto.Encoder, msg proto.Message) {
+		tag := msg.Header().Tag()
+		switch m := msg.(type) {
+		case *proto.ReadReq:
+			tags <- tag // never answered
+		case *proto.FlushReq:
+			flushed <- m.Oldtag()
+			enc.Rflush(tag)
+		default:
+			enc.Rclunk(tag)
+		}
+	})
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := conn.Read(ctx, 1, 0, 8)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	tag := <-tags
+	assert.Equal(t, <-flushed, tag)
+	assert.NilError(t, conn.Clunk(context.Background(), 1))
+
+	// the tag of the flushed request is released after the Rflush
+	for i := 0; ; i++ {
+		conn.mu.Lock()
+		n := len(conn.pending)
+		conn.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("%d tags still pending", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConnFlushRace(t *testing.T) {
+	tags := make(chan plan9.Tag, 1)
+	conn := testServer(t, func(enc *proto.Encoder, msg proto.Message) {
+		tag := msg.Header().Tag()
+		switch m := msg.(type) {
+		case *proto.ReadReq:
+			tags <- tag
+		case *proto.FlushReq:
+			// the read is answered before the Rflush
+			enc.Rread(m.Oldtag(), []byte("late"))
+			enc.Rflush(tag)
+		}
+	})
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	b, err := conn.Read(ctx, 1, 0, 8)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "late")
+	<-tags
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	assert.Equal(t, len(conn.pending), 0)
+	assert.Equal(t, len(conn.flushing), 0)
+}
+
+func TestConnClose(t *testing.T) {
+	conn := testServer(t, func(enc *proto.Encoder, msg proto.Message) {})
+	errc := make(chan error)
+	go func() {
+		_, err := conn.Read(context.Background(), 1, 0, 8)
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	conn.Close()
+	assert.Equal(t, <-errc, ErrClosed)
+	_, err := conn.Read(context.Background(), 1, 0, 8)
+	assert.Equal(t, err, ErrClosed)
+}
@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"plan9.io"
+)
+
+// maxWelem is the most names a single Twalk may carry.
+const maxWelem = 16
+
+var errFids = errors.New("9p: out of fids")
+
+// newFid allocates a fid, never plan9.NoFID.
+func (c *Conn) newFid() (plan9.FID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n := len(c.fids); n > 0 {
+		fid := c.fids[n-1]
+		c.fids = c.fids[:n-1]
+		return fid, nil
+	}
+	if c.nextFid == plan9.NoFID {
+		return 0, errFids
+	}
+	fid := c.nextFid
+	c.nextFid++
+	return fid, nil
+}
+
+// releaseFid returns a fid the server has forgotten.
+func (c *Conn) releaseFid(fid plan9.FID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fids = append(c.fids, fid)
+}
+
+// forgetFid returns fid, the newfid of a failed Tattach or Twalk. Unless
+// the failure was an Rerror the server may hold fid, so it is clunked first.
+func (c *Conn) forgetFid(fid plan9.FID, err error) {
+	if !errors.As(err, new(*Error)) {
+		c.Clunk(context.Background(), fid)
+	}
+	c.releaseFid(fid)
+}
+
+// A File is a fid on a Conn, representing a file on the server.
+// Reads and writes are split into messages of at most the iounit of the
+// open file. The io methods use context.Background.
+type File struct {
+	c      *Conn
+	fid    plan9.FID
+	qid    plan9.QID
+	iounit uint32
+
+	mu      sync.Mutex // guards offset and clunked
+	offset  int64
+	clunked bool
+}
+
+// Root attaches to the root of the file tree aname as uname.
+func (c *Conn) Root(ctx context.Context, uname, aname string) (*File, error) {
+	fid, err := c.newFid()
+	if err != nil {
+		return nil, err
+	}
+	qid, err := c.Attach(ctx, fid, plan9.NoFID, uname, aname)
+	if err != nil {
+		c.forgetFid(fid, err)
+		return nil, err
+	}
+	return &File{c: c, fid: fid, qid: qid}, nil
+}
+
+// Fid returns the fid of f.
+func (f *File) Fid() plan9.FID { return f.fid }
+
+// QID returns the qid of the file.
+func (f *File) QID() plan9.QID { return f.qid }
+
+// Walk returns a new File for the file reached from f through names,
+// or a copy of f if there are none. Walks of more than 16 names are split.
+func (f *File) Walk(ctx context.Context, names ...string) (*File, error) {
+	newfid, err := f.c.newFid()
+	if err != nil {
+		return nil, err
+	}
+	nf := &File{c: f.c, fid: newfid, qid: f.qid}
+	fid, walked := f.fid, 0
+	for first := true; first || walked < len(names); first = false {
+		wname := names[walked:]
+		if len(wname) > maxWelem {
+			wname = wname[:maxWelem]
+		}
+		qids, err := f.c.Walk(ctx, fid, newfid, wname...)
+		short := err == nil && len(qids) < len(wname)
+		if short {
+			err = fmt.Errorf("9p: walk %s: %w", strings.Join(names[:walked+len(qids)+1], "/"), fs.ErrNotExist)
+		}
+		if err != nil {
+			switch {
+			case walked > 0:
+				nf.Close()
+			case short:
+				// a short Rwalk leaves newfid unbound
+				f.c.releaseFid(newfid)
+			default:
+				f.c.forgetFid(newfid, err)
+			}
+			return nil, err
+		}
+		if len(qids) > 0 {
+			nf.qid = qids[len(qids)-1]
+		}
+		fid, walked = newfid, walked+len(wname)
+	}
+	return nf, nil
+}
+
+// Open opens f with mode, one of the plan9.O constants.
+func (f *File) Open(ctx context.Context, mode uint8) error {
+	qid, iounit, err := f.c.Open(ctx, f.fid, mode)
+	if err != nil {
+		return err
+	}
+	f.qid, f.iounit = qid, iounit
+	return nil
+}
+
+// Create creates name in the directory f and opens it with mode,
+// after which f represents the new file.
+func (f *File) Create(ctx context.Context, name string, perm plan9.Perm, mode uint8) error {
+	qid, iounit, err := f.c.Create(ctx, f.fid, name, perm, mode)
+	if err != nil {
+		return err
+	}
+	f.qid, f.iounit = qid, iounit
+	return nil
+}
+
+// Stat returns the directory entry of f.
+func (f *File) Stat(ctx context.Context) (*plan9.Dir, error) {
+	return f.c.Stat(ctx, f.fid)
+}
+
+// Wstat changes the directory entry of f to d.
+func (f *File) Wstat(ctx context.Context, d *plan9.Dir) error {
+	return f.c.Wstat(ctx, f.fid, d)
+}
+
+// Remove removes the file and releases f, even if the remove fails.
+func (f *File) Remove(ctx context.Context) error {
+	if !f.release() {
+		return fs.ErrClosed
+	}
+	err := f.c.Remove(ctx, f.fid)
+	f.c.releaseFid(f.fid)
+	return err
+}
+
+// Clunk releases f.
+func (f *File) Clunk(ctx context.Context) error {
+	if !f.release() {
+		return fs.ErrClosed
+	}
+	err := f.c.Clunk(ctx, f.fid)
+	f.c.releaseFid(f.fid)
+	return err
+}
+
+// release marks f as released, reporting whether it was not already, so
+// that its fid is only released once.
+func (f *File) release() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.clunked {
+		return false
+	}
+	f.clunked = true
+	return true
+}
+
+// Close releases f.
+func (f *File) Close() error { return f.Clunk(context.Background()) }
+
+// chunk is the most data a single Tread or Twrite on f may carry.
+func (f *File) chunk() int {
+	n := f.c.session.Msize - plan9.IOHDRSZ
+	if f.iounit != 0 && f.iounit < n {
+		n = f.iounit
+	}
+	return int(n)
+}
+
+// read reads into p at off until p is full or the server returns less
+// than asked for.
+func (f *File) read(p []byte, off int64) (int, error) {
+	ctx := context.Background()
+	n := 0
+	for n < len(p) {
+		want := len(p) - n
+		if want > f.chunk() {
+			want = f.chunk()
+		}
+		data, err := f.c.Read(ctx, f.fid, uint64(off)+uint64(n), uint32(want))
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], data)
+		if len(data) < want {
+			break
+		}
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (f *File) write(p []byte, off int64) (int, error) {
+	ctx := context.Background()
+	n := 0
+	for n < len(p) {
+		data := p[n:]
+		if len(data) > f.chunk() {
+			data = data[:f.chunk()]
+		}
+		m, err := f.c.Write(ctx, f.fid, uint64(off)+uint64(n), data)
+		n += int(m)
+		if err != nil {
+			return n, err
+		}
+		if int(m) < len(data) {
+			return n, io.ErrShortWrite
+		}
+	}
+	return n, nil
+}
+
+// Read reads from the current offset of f.
+func (f *File) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.read(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes at off, returning io.EOF if there are fewer.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("9p: negative offset %d", off)
+	}
+	n := 0
+	for n < len(p) {
+		m, err := f.read(p[n:], off+int64(n))
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Write writes at the current offset of f.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.write(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// WriteAt writes p at off.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("9p: negative offset %d", off)
+	}
+	return f.write(p, off)
+}
+
+// Seek sets the offset of the next Read or Write. Seeking relative to the
+// end of the file costs a Tstat.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		d, err := f.Stat(context.Background())
+		if err != nil {
+			return f.offset, err
+		}
+		offset += int64(d.Length)
+	default:
+		return f.offset, fmt.Errorf("9p: bad whence %d", whence)
+	}
+	if offset < 0 {
+		return f.offset, fmt.Errorf("9p: negative offset %d", offset)
+	}
+	f.offset = offset
+	return offset, nil
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+// testFS serves a root directory holding the file "hello", with an iounit
+// of 4 so that reads and writes are split.
+type testFS struct {
+	fids    map[plan9.FID]string
+	content []byte
+	reads   int
+}
+
+func (fs *testFS) handle(enc *proto.Encoder, msg proto.Message) {
+	tag := msg.Header().Tag()
+	qid := func(name string) plan9.QID {
+		if name == "" {
+			return plan9.QID{Type: plan9.QTDIR}
+		}
+		return plan9.QID{Path: 1}
+	}
+	switch m := msg.(type) {
+	case *proto.AttachReq:
+		fs.fids[m.Fid()] = ""
+		enc.Rattach(tag, qid(""))
+	case *proto.WalkReq:
+		name, ok := fs.fids[m.Fid()]
+		if !ok {
+			enc.Rerror(tag, "unknown fid", 0)
+			return
+		}
+		var qids []plan9.QID
+		for _, w := range m.Wname() {
+			if name != "" || w != "hello" {
+				break
+			}
+			name = w
+			qids = append(qids, qid(name))
+		}
+		if len(qids) == 0 && len(m.Wname()) > 0 {
+			enc.Rerror(tag, "file does not exist", 0)
+			return
+		}
+		if len(qids) == len(m.Wname()) {
+			fs.fids[m.Newfid()] = name
+		}
+		enc.Rwalk(tag, qids)
+	case *proto.OpenReq:
+		if m.Mode()&plan9.OTRUNC != 0 {
+			fs.content = nil
+		}
+		enc.Ropen(tag, qid(fs.fids[m.Fid()]), 4)
+	case *proto.ReadReq:
+		fs.reads++
+		off, end := int(m.Offset()), int(m.Offset())+int(m.Count())
+		if off > len(fs.content) {
+			off = len(fs.content)
+		}
+		if end > len(fs.content) {
+			end = len(fs.content)
+		}
+		enc.Rread(tag, fs.content[off:end])
+	case *proto.WriteReq:
+		off := int(m.Offset())
+		for len(fs.content) < off+len(m.Data()) {
+			fs.content = append(fs.content, 0)
+		}
+		copy(fs.content[off:], m.Data())
+		enc.Rwrite(tag, uint32(len(m.Data())))
+	case *proto.StatReq:
+		enc.Rstat(tag, &plan9.Dir{Name: fs.fids[m.Fid()], Length: uint64(len(fs.content))})
+	case *proto.ClunkReq:
+		delete(fs.fids, m.Fid())
+		enc.Rclunk(tag)
+	}
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	fs := &testFS{fids: map[plan9.FID]string{}, content: []byte("hello, world")}
+	conn := testServer(t, fs.handle)
+	defer conn.Close()
+
+	root, err := conn.Root(ctx, "glenda", "")
+	assert.NilError(t, err)
+	assert.Equal(t, root.QID().Type, uint8(plan9.QTDIR))
+
+	_, err = root.Walk(ctx, "nope")
+	assert.DeepEqual(t, err, &Error{Ename: "file does not exist"})
+	_, err = root.Walk(ctx, "hello", "nope")
+	if err == nil {
+		t.Fatal("partial walk succeeded")
+	}
+
+	f, err := root.Walk(ctx, "hello")
+	assert.NilError(t, err)
+	if f.Fid() == root.Fid() || f.Fid() == plan9.NoFID {
+		t.Fatalf("walked fid %d, root fid %d", f.Fid(), root.Fid())
+	}
+	assert.NilError(t, f.Open(ctx, plan9.ORDWR))
+
+	b, err := ioutil.ReadAll(f)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "hello, world")
+	assert.Equal(t, fs.reads, 5)
+
+	p := make([]byte, 5)
+	n, err := f.ReadAt(p, 7)
+	assert.NilError(t, err)
+	assert.Equal(t, string(p[:n]), "world")
+	_, err = f.ReadAt(p, 8)
+	assert.Equal(t, err, io.EOF)
+	if _, err := f.ReadAt(p, -1); err == nil {
+		t.Fatal("ReadAt at a negative offset succeeded")
+	}
+	if _, err := f.WriteAt(p, -1); err == nil {
+		t.Fatal("WriteAt at a negative offset succeeded")
+	}
+
+	off, err := f.Seek(-5, io.SeekEnd)
+	assert.NilError(t, err)
+	assert.Equal(t, off, int64(7))
+	_, err = io.Copy(f, strings.NewReader("glenda!"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(fs.content), "hello, glenda!")
+
+	_, err = f.WriteAt([]byte("J"), 0)
+	assert.NilError(t, err)
+	assert.Equal(t, string(fs.content), "Jello, glenda!")
+
+	assert.NilError(t, f.Close())
+	assert.NilError(t, root.Close())
+	assert.Equal(t, len(fs.fids), 0)
+}
+
+func TestFidReuse(t *testing.T) {
+	ctx := context.Background()
+	fs := &testFS{fids: map[plan9.FID]string{}}
+	conn := testServer(t, fs.handle)
+	defer conn.Close()
+
+	root, err := conn.Root(ctx, "glenda", "")
+	assert.NilError(t, err)
+	f, err := root.Walk(ctx)
+	assert.NilError(t, err)
+	fid := f.Fid()
+	assert.NilError(t, f.Close())
+	_, err = root.Walk(ctx, "nope")
+	assert.DeepEqual(t, err, &Error{Ename: "file does not exist"})
+	f, err = root.Walk(ctx, "hello")
+	assert.NilError(t, err)
+	assert.Equal(t, f.Fid(), fid)
+
+	// a fid closed twice is released once
+	assert.NilError(t, f.Close())
+	if f.Close() == nil {
+		t.Fatal("second Close succeeded")
+	}
+	f, err = root.Walk(ctx)
+	assert.NilError(t, err)
+	g, err := root.Walk(ctx)
+	assert.NilError(t, err)
+	if f.Fid() == g.Fid() {
+		t.Fatalf("fid %d in use twice", f.Fid())
+	}
+}
+
+func TestWalkCancel(t *testing.T) {
+	clunked := make(chan plan9.FID, 1)
+	conn := testServer(t, func(enc *proto.Encoder, msg proto.Message) {
+		tag := msg.Header().Tag()
+		switch m := msg.(type) {
+		case *proto.AttachReq:
+			enc.Rattach(tag, plan9.QID{Type: plan9.QTDIR})
+		case *proto.WalkReq:
+			// never answered
+		case *proto.FlushReq:
+			enc.Rflush(tag)
+		case *proto.ClunkReq:
+			clunked <- m.Fid()
+			enc.Rclunk(tag)
+		}
+	})
+	defer conn.Close()
+
+	root, err := conn.Root(context.Background(), "glenda", "")
+	assert.NilError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = root.Walk(ctx, "hello")
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	// the server may hold the newfid of an unanswered walk
+	fid := <-clunked
+	if fid == root.Fid() {
+		t.Fatalf("clunked the root fid %d", fid)
+	}
+}
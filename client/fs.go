@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+// FS is the file tree below a File, as an fs.FS.
+type FS struct {
+	root *File
+}
+
+var (
+	_ fs.ReadDirFS = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+)
+
+// NewFS returns the file tree below root. Closing root invalidates it.
+func NewFS(root *File) *FS { return &FS{root: root} }
+
+// walk walks a new File to name.
+func (fsys *FS) walk(op, name string) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	var names []string
+	if name != "." {
+		names = strings.Split(name, "/")
+	}
+	f, err := fsys.root.Walk(context.Background(), names...)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return f, nil
+}
+
+// Open opens the named file for reading.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	f, err := fsys.walk("open", name)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	d, err := f.Stat(ctx)
+	if err == nil {
+		err = f.Open(ctx, plan9.OREAD)
+	}
+	if err != nil {
+		f.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &fsFile{File: f, info: fileInfo{d}}, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	f, err := fsys.walk("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := f.Stat(context.Background())
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return fileInfo{d}, nil
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := f.(*fsFile).ReadDir(-1)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, err
+}
+
+// fsFile is an open File, implementing fs.ReadDirFile for directories.
+type fsFile struct {
+	*File
+	info    fileInfo
+	dr      *proto.DirReader
+	entries []fs.DirEntry // read from the server but not returned yet
+	eof     bool
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *fsFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.info.Name(), Err: fs.ErrInvalid}
+	}
+	for !f.eof && (n <= 0 || len(f.entries) < n) {
+		if err := f.fill(); err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: f.info.Name(), Err: err}
+		}
+	}
+	m := n
+	if m <= 0 || m > len(f.entries) {
+		m = len(f.entries)
+	}
+	entries := f.entries[:m:m]
+	f.entries = f.entries[m:]
+	if len(entries) == 0 && n > 0 {
+		return nil, io.EOF
+	}
+	return entries, nil
+}
+
+// fill reads a directory entry.
+func (f *fsFile) fill() error {
+	if f.dr == nil {
+		f.dr = proto.NewDirReader(f.File, f.c.session.Dialect)
+	}
+	d, err := f.dr.Next()
+	if err == io.EOF {
+		f.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f.entries = append(f.entries, dirEntry{fileInfo{d}})
+	return nil
+}
+
+// fileInfo is a Dir as an fs.FileInfo.
+type fileInfo struct {
+	d *plan9.Dir
+}
+
+func (fi fileInfo) Name() string       { return fi.d.Name }
+func (fi fileInfo) Size() int64        { return int64(fi.d.Length) }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.d.Mode.FileMode() }
+func (fi fileInfo) ModTime() time.Time { return time.Unix(int64(fi.d.Mtime), 0) }
+func (fi fileInfo) IsDir() bool        { return fi.d.Mode&plan9.DMDIR != 0 }
+func (fi fileInfo) Sys() interface{}   { return fi.d }
+
+// dirEntry is a Dir as an fs.DirEntry.
+type dirEntry struct {
+	fi fileInfo
+}
+
+func (de dirEntry) Name() string               { return de.fi.Name() }
+func (de dirEntry) IsDir() bool                { return de.fi.IsDir() }
+func (de dirEntry) Type() fs.FileMode          { return de.fi.Mode().Type() }
+func (de dirEntry) Info() (fs.FileInfo, error) { return de.fi, nil }
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+// testTree serves a read-only tree of files, given by their contents.
+type testTree struct {
+	files map[string]string
+	paths []string // every file and directory, "." for the root
+	fids  map[plan9.FID]string
+}
+
+func newTestTree(files map[string]string) *testTree {
+	t := &testTree{files: files, fids: map[plan9.FID]string{}}
+	seen := map[string]bool{".": true}
+	t.paths = []string{"."}
+	for name := range files {
+		for p := name; p != "."; p = path.Dir(p) {
+			if !seen[p] {
+				seen[p] = true
+				t.paths = append(t.paths, p)
+			}
+		}
+	}
+	sort.Strings(t.paths)
+	return t
+}
+
+func (t *testTree) dir(p string) *plan9.Dir {
+	d := &plan9.Dir{Name: path.Base(p), UID: "glenda", GID: "glenda", Muid: "glenda", Mtime: 1577777777}
+	d.QID.Path = uint64(sort.SearchStrings(t.paths, p))
+	if content, ok := t.files[p]; ok {
+		d.Mode, d.Length = 0644, uint64(len(content))
+	} else {
+		d.Mode, d.QID.Type = plan9.DMDIR|0755, plan9.QTDIR
+	}
+	return d
+}
+
+func (t *testTree) handle(enc *proto.Encoder, msg proto.Message) {
+	tag := msg.Header().Tag()
+	switch m := msg.(type) {
+	case *proto.AttachReq:
+		t.fids[m.Fid()] = "."
+		enc.Rattach(tag, t.dir(".").QID)
+	case *proto.WalkReq:
+		p := t.fids[m.Fid()]
+		var qids []plan9.QID
+		for _, w := range m.Wname() {
+			next := path.Join(p, w)
+			if i := sort.SearchStrings(t.paths, next); i == len(t.paths) || t.paths[i] != next {
+				break
+			}
+			p = next
+			qids = append(qids, t.dir(p).QID)
+		}
+		if len(qids) == 0 && len(m.Wname()) > 0 {
+			enc.Rerror(tag, "file does not exist", 0)
+			return
+		}
+		if len(qids) == len(m.Wname()) {
+			t.fids[m.Newfid()] = p
+		}
+		enc.Rwalk(tag, qids)
+	case *proto.OpenReq:
+		enc.Ropen(tag, t.dir(t.fids[m.Fid()]).QID, 128)
+	case *proto.ReadReq:
+		p := t.fids[m.Fid()]
+		content, ok := t.files[p]
+		if ok {
+			data := []byte(content)
+			off, end := int(m.Offset()), int(m.Offset())+int(m.Count())
+			if off > len(data) {
+				off = len(data)
+			}
+			if end > len(data) {
+				end = len(data)
+			}
+			enc.Rread(tag, data[off:end])
+			return
+		}
+		// directories are read in whole entries, so the offset is at one
+		var data []byte
+		for _, child := range t.paths {
+			if child != "." && path.Dir(child) == p {
+				b, _ := t.dir(child).MarshalBinary()
+				data = append(data, b...)
+			}
+		}
+		data = data[m.Offset():]
+		n := 0
+		for n < len(data) {
+			size := 2 + int(binary.LittleEndian.Uint16(data[n:]))
+			if n+size > int(m.Count()) {
+				break
+			}
+			n += size
+		}
+		enc.Rread(tag, data[:n])
+	case *proto.StatReq:
+		enc.Rstat(tag, t.dir(t.fids[m.Fid()]))
+	case *proto.ClunkReq:
+		delete(t.fids, m.Fid())
+		enc.Rclunk(tag)
+	}
+}
+
+func TestFS(t *testing.T) {
+	tree := newTestTree(map[string]string{
+		"lib/profile":       "bind -a $home/bin/$cputype /bin\n",
+		"lib/plumbing":      "include basic\n",
+		"bin/rc/g":          "#!/bin/rc\n",
+		"tmp/x":             strings.Repeat("x", 1000),
+		"empty/dir/.keep":   "",
+		"a/b/c/d/e/f/g/h/i": "deep",
+	})
+	conn := testServer(t, tree.handle)
+	defer conn.Close()
+	root, err := conn.Root(context.Background(), "glenda", "")
+	assert.NilError(t, err)
+	fsys := NewFS(root)
+
+	if err := fstest.TestFS(fsys, "lib/profile", "lib/plumbing", "bin/rc/g", "tmp/x", "a/b/c/d/e/f/g/h/i"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := fs.ReadFile(fsys, "lib/profile")
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), tree.files["lib/profile"])
+
+	fi, err := fs.Stat(fsys, "lib")
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode(), fs.ModeDir|0755)
+
+	_, err = fsys.Open("lib/nope")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Open of a missing file: %v, want fs.ErrNotExist", err)
+	}
+	_, err = fsys.Open("lib/profile/nope")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Open below a file: %v, want fs.ErrNotExist", err)
+	}
+	_, err = fsys.Open("/lib")
+	if !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Open of an invalid path: %v, want fs.ErrInvalid", err)
+	}
+}
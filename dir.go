@@ -0,0 +1,88 @@
+package plan9
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// NullDir returns a Dir whose fields all mean "leave unchanged" in a
+// Twstat: integers of all ones and empty strings.
+func NullDir() Dir {
+	return Dir{
+		Type:   ^uint16(0),
+		Dev:    ^uint32(0),
+		QID:    QID{Path: ^uint64(0), Vers: ^uint32(0), Type: ^uint8(0)},
+		Mode:   ^Perm(0),
+		Atime:  ^uint32(0),
+		Mtime:  ^uint32(0),
+		Length: ^uint64(0),
+		NUID:   ^uint32(0),
+		NGID:   ^uint32(0),
+		NMUID:  ^uint32(0),
+	}
+}
+
+// WithName returns a copy of d renamed to name, so that
+// NullDir().WithName(name) is the Twstat renaming a file.
+func (d Dir) WithName(name string) Dir { d.Name = name; return d }
+
+// WithMode returns a copy of d with the mode and permissions mode.
+func (d Dir) WithMode(mode Perm) Dir { d.Mode = mode; return d }
+
+// WithLength returns a copy of d with the length length, truncating or
+// extending the file in a Twstat.
+func (d Dir) WithLength(length uint64) Dir { d.Length = length; return d }
+
+// WithMtime returns a copy of d with the modification time mtime.
+func (d Dir) WithMtime(mtime uint32) Dir { d.Mtime = mtime; return d }
+
+// WithGID returns a copy of d with the group gid.
+func (d Dir) WithGID(gid string) Dir { d.GID = gid; return d }
+
+// WireSize returns the size of the 9P2000 stat record of d, including its
+// leading size[2]: the length of a record in the data of a directory read,
+// and of the record MarshalBinary returns. The stat[n] field of Rstat and
+// Twstat adds another count n[2] of WireSize bytes in front of the record.
+func (d *Dir) WireSize() int {
+	return 2 + 2 + 4 + 13 + 4 + 4 + 4 + 8 + 4*2 + len(d.Name) + len(d.UID) + len(d.GID) + len(d.Muid)
+}
+
+// MarshalBinary encodes d as a 9P2000 stat record, as found in the data
+// of a directory read. The 9P2000.u fields are left out.
+func (d *Dir) MarshalBinary() ([]byte, error) {
+	return d.AppendBinary(make([]byte, 0, d.WireSize()))
+}
+
+// AppendBinary appends the record MarshalBinary returns to b. The encoding
+// package appends the 9P2000.u fields to it for 9P2000.u.
+func (d *Dir) AppendBinary(b []byte) ([]byte, error) {
+	n := d.WireSize()
+	if n-2 > 0xffff {
+		return nil, fmt.Errorf("9p: stat of %q too large", d.Name)
+	}
+	i := len(b)
+	if cap(b)-i < n {
+		nb := make([]byte, i, 2*cap(b)+n)
+		copy(nb, b)
+		b = nb
+	}
+	b = b[:i+n]
+	r := b[i:]
+	le := binary.LittleEndian
+	le.PutUint16(r[0:], uint16(n-2))
+	le.PutUint16(r[2:], d.Type)
+	le.PutUint32(r[4:], d.Dev)
+	r[8] = d.QID.Type
+	le.PutUint32(r[9:], d.QID.Vers)
+	le.PutUint64(r[13:], d.QID.Path)
+	le.PutUint32(r[21:], uint32(d.Mode))
+	le.PutUint32(r[25:], d.Atime)
+	le.PutUint32(r[29:], d.Mtime)
+	le.PutUint64(r[33:], d.Length)
+	i = 41
+	for _, s := range [...]string{d.Name, d.UID, d.GID, d.Muid} {
+		le.PutUint16(r[i:], uint16(len(s)))
+		i += 2 + copy(r[i+2:], s)
+	}
+	return b, nil
+}
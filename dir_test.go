@@ -0,0 +1,35 @@
+package plan9
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+)
+
+func TestNullDir(t *testing.T) {
+	d := NullDir().WithName("new").WithMode(0600).WithLength(0).WithMtime(1).WithGID("sys")
+	want := NullDir()
+	want.Name, want.Mode, want.Length, want.Mtime, want.GID = "new", 0600, 0, 1, "sys"
+	assert.DeepEqual(t, d, want)
+	assert.Equal(t, NullDir().QID, QID{Path: ^uint64(0), Vers: ^uint32(0), Type: ^uint8(0)})
+	assert.Equal(t, NullDir().Name, "")
+}
+
+func TestDirMarshalBinary(t *testing.T) {
+	d := Dir{QID: QID{Path: 1, Type: QTDIR}, Mode: DMDIR | 0755, Name: "lib", UID: "glenda", GID: "sys"}
+	b, err := d.MarshalBinary()
+	assert.NilError(t, err)
+	assert.Equal(t, len(b), 2+39+2*4+len("lib")+len("glenda")+len("sys"))
+	assert.Equal(t, len(b), d.WireSize())
+	assert.Equal(t, int(b[0])|int(b[1])<<8, len(b)-2)
+	p, err := d.AppendBinary([]byte("prefix"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(p), "prefix"+string(b))
+
+	d.Name = strings.Repeat("x", 1<<16)
+	_, err = d.MarshalBinary()
+	if err == nil {
+		t.Fatal("MarshalBinary encoded a name too long for a stat")
+	}
+}
@@ -1,28 +1,56 @@
 package plan9
 
 import (
+	"io"
+
 	"plan9.io"
 )
 
-func guint8(b []byte) (uint8, []byte) {
-	return uint8(b[0]), b[1:]
+func guint8(b []byte) (uint8, []byte, error) {
+	if len(b) < 1 {
+		return 0, b, io.ErrUnexpectedEOF
+	}
+	return uint8(b[0]), b[1:], nil
 }
 
-func guint16(b []byte) (uint16, []byte) {
-	return uint16(b[0]) | uint16(b[1])<<8, b[2:]
+func guint16(b []byte) (uint16, []byte, error) {
+	if len(b) < 2 {
+		return 0, b, io.ErrUnexpectedEOF
+	}
+	return uint16(b[0]) | uint16(b[1])<<8, b[2:], nil
 }
 
-func guint32(b []byte) (uint32, []byte) {
-	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, b[4:]
+func guint32(b []byte) (uint32, []byte, error) {
+	if len(b) < 4 {
+		return 0, b, io.ErrUnexpectedEOF
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, b[4:], nil
 }
 
-func guint64(b []byte) (uint64, []byte) {
-	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 | uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56, b[8:]
+func guint64(b []byte) (uint64, []byte, error) {
+	if len(b) < 8 {
+		return 0, b, io.ErrUnexpectedEOF
+	}
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 | uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56, b[8:], nil
 }
 
-func gstring(b []byte) (string, []byte) {
-	n, b := guint16(b)
-	return string(b[0:n]), b[n:]
+func gstring(b []byte) (string, []byte, error) {
+	n, rest, err := guint16(b)
+	if err != nil {
+		return "", b, err
+	}
+	if int(n) > len(rest) {
+		return "", b, ErrStringMalformed
+	}
+	return string(rest[0:n]), rest[n:], nil
+}
+
+// gdata returns a copy of the first n bytes of b, so messages don't alias the decoder's buffer.
+func gdata(b []byte, n uint32) ([]byte, []byte, error) {
+	if uint64(n) > uint64(len(b)) {
+		return nil, b, io.ErrUnexpectedEOF
+	}
+	return append([]byte(nil), b[:n]...), b[n:], nil
 }
 
 func pbit8(b []byte, x uint8) []byte {
@@ -71,50 +99,215 @@ func pbit64(b []byte, x uint64) []byte {
 	return b
 }
 
-func pstring(b []byte, s string) []byte {
+// ErrStringTooLong is returned when marshaling a string longer than its
+// size[2] can count.
+var ErrStringTooLong = ProtocolError("string too long")
+
+func pstring(b []byte, s string) ([]byte, error) {
 	if len(s) >= 1<<16 {
-		panic("string too long")
+		return b, ErrStringTooLong
 	}
 	b = pbit16(b, uint16(len(s)))
 	b = append(b, []byte(s)...)
-	return b
+	return b, nil
 }
-func gtag(b []byte) (plan9.Tag, []byte) {
-	return plan9.Tag(b[0]) | plan9.Tag(b[1])<<8, b[2:]
+func gtag(b []byte) (plan9.Tag, []byte, error) {
+	t, b, err := guint16(b)
+	return plan9.Tag(t), b, err
 }
 
-func gfid(b []byte) (plan9.FID, []byte) {
-	var t uint32
-	t, b = guint32(b)
-	return plan9.FID(t), b
+func gfid(b []byte) (plan9.FID, []byte, error) {
+	f, b, err := guint32(b)
+	return plan9.FID(f), b, err
 }
-func unmarshaldir(b []byte) (*plan9.Dir, []byte) {
-	n, b := guint16(b)
-	if int(n) > len(b) {
-		panic(1)
+
+// unmarshaldir decodes a stat record, with the 9P2000.u fields if dotu is set.
+func unmarshaldir(b []byte, dotu bool) (*plan9.Dir, []byte, error) {
+	n, rest, err := guint16(b)
+	if err != nil {
+		return nil, b, err
+	}
+	if int(n) > len(rest) {
+		return nil, b, io.ErrUnexpectedEOF
 	}
 	d := new(plan9.Dir)
-	d.Type, b = guint16(b)
-	d.Dev, b = guint32(b)
-	d.QID, b = gqid(b)
-	d.Mode, b = gperm(b)
-	d.Atime, b = guint32(b)
-	d.Mtime, b = guint32(b)
-	d.Length, b = guint64(b)
-	d.Name, b = gstring(b)
-	d.UID, b = gstring(b)
-	d.GID, b = gstring(b)
-	d.Muid, b = gstring(b)
-	return d, b
-}
-func gqid(b []byte) (plan9.QID, []byte) {
+	s := rest[:n]
+	if d.Type, s, err = guint16(s); err != nil {
+		return nil, b, err
+	}
+	if d.Dev, s, err = guint32(s); err != nil {
+		return nil, b, err
+	}
+	if d.QID, s, err = gqid(s); err != nil {
+		return nil, b, err
+	}
+	if d.Mode, s, err = gperm(s); err != nil {
+		return nil, b, err
+	}
+	if d.Atime, s, err = guint32(s); err != nil {
+		return nil, b, err
+	}
+	if d.Mtime, s, err = guint32(s); err != nil {
+		return nil, b, err
+	}
+	if d.Length, s, err = guint64(s); err != nil {
+		return nil, b, err
+	}
+	if d.Name, s, err = gstring(s); err != nil {
+		return nil, b, err
+	}
+	if d.UID, s, err = gstring(s); err != nil {
+		return nil, b, err
+	}
+	if d.GID, s, err = gstring(s); err != nil {
+		return nil, b, err
+	}
+	if d.Muid, s, err = gstring(s); err != nil {
+		return nil, b, err
+	}
+	if dotu {
+		if d.Extension, s, err = gstring(s); err != nil {
+			return nil, b, err
+		}
+		if d.NUID, s, err = guint32(s); err != nil {
+			return nil, b, err
+		}
+		if d.NGID, s, err = guint32(s); err != nil {
+			return nil, b, err
+		}
+		if d.NMUID, _, err = guint32(s); err != nil {
+			return nil, b, err
+		}
+	}
+	return d, rest[n:], nil
+}
+
+func gqid(b []byte) (plan9.QID, []byte, error) {
+	if len(b) < 13 {
+		return plan9.QID{}, b, io.ErrUnexpectedEOF
+	}
 	var q plan9.QID
-	q.Type, b = guint8(b)
-	q.Vers, b = guint32(b)
-	q.Path, b = guint64(b)
-	return q, b
-}
-func gperm(b []byte) (plan9.Perm, []byte) {
-	p, b := guint32(b)
-	return plan9.Perm(p), b
+	q.Type, b, _ = guint8(b)
+	q.Vers, b, _ = guint32(b)
+	q.Path, b, _ = guint64(b)
+	return q, b, nil
+}
+
+func gperm(b []byte) (plan9.Perm, []byte, error) {
+	p, b, err := guint32(b)
+	return plan9.Perm(p), b, err
+}
+
+func pheader(b []byte, t plan9.MessageType, tag plan9.Tag) []byte {
+	b = pbit32(b, 0) // size, filled in by psize
+	b = pbit8(b, uint8(t))
+	return ptag(b, tag)
+}
+
+// psize writes the length of the message in b to its size[4] field.
+func psize(b []byte) {
+	encoder.PutUint32(b, uint32(len(b)))
+}
+
+func ptag(b []byte, t plan9.Tag) []byte {
+	return pbit16(b, uint16(t))
+}
+
+func pfid(b []byte, f plan9.FID) []byte {
+	return pbit32(b, uint32(f))
+}
+
+func pqid(b []byte, q plan9.QID) []byte {
+	b = pbit8(b, q.Type)
+	b = pbit32(b, q.Vers)
+	b = pbit64(b, q.Path)
+	return b
+}
+
+func pperm(b []byte, p plan9.Perm) []byte {
+	return pbit32(b, uint32(p))
+}
+
+// dirsize is the size of d's stat record, not counting its own size[2].
+// A nil d is marshaled as the zero Dir.
+func dirsize(d *plan9.Dir, dotu bool) int {
+	if d == nil {
+		d = new(plan9.Dir)
+	}
+	n := d.WireSize() - 2
+	if dotu {
+		n += 2 + len(d.Extension) + 4 + 4 + 4
+	}
+	return n
+}
+
+// ErrStatSize is returned for a stat[n] field whose count n disagrees
+// with the size of the stat record it holds.
+var ErrStatSize = ProtocolError("stat size disagrees with its count")
+
+// ErrStatTooLarge is returned when marshaling a stat record larger than
+// its size[2] can count.
+var ErrStatTooLarge = ProtocolError("stat record too large")
+
+// The stat[n] field of Rstat and Twstat is a count n[2] followed by a stat
+// record of n bytes, which starts with its own size[2] of n-2; see stat(5).
+
+// statsize is the size of the stat[n] field holding d.
+func statsize(d *plan9.Dir, dotu bool) int {
+	return 2 + 2 + dirsize(d, dotu)
+}
+
+// unmarshalstat decodes a stat[n] field, checking that n agrees with the
+// size of the record.
+func unmarshalstat(b []byte, dotu bool) (*plan9.Dir, []byte, error) {
+	n, rest, err := guint16(b)
+	if err != nil {
+		return nil, b, err
+	}
+	size, _, err := guint16(rest)
+	if err != nil {
+		return nil, b, err
+	}
+	if int(size)+2 != int(n) {
+		return nil, b, ErrStatSize
+	}
+	d, rest, err := unmarshaldir(rest, dotu)
+	if err != nil {
+		return nil, b, err
+	}
+	return d, rest, nil
+}
+
+// marshalstat appends the stat[n] field holding d.
+func marshalstat(b []byte, d *plan9.Dir, dotu bool) ([]byte, error) {
+	n := 2 + dirsize(d, dotu)
+	if n > 0xffff {
+		return nil, ErrStatTooLarge
+	}
+	b = pbit16(b, uint16(n))
+	return marshaldir(b, d, dotu)
+}
+
+func marshaldir(b []byte, d *plan9.Dir, dotu bool) ([]byte, error) {
+	if d == nil {
+		d = new(plan9.Dir)
+	}
+	n := dirsize(d, dotu)
+	if n > 0xffff {
+		return nil, ErrStatTooLarge
+	}
+	i := len(b)
+	b, err := d.AppendBinary(b)
+	if err != nil || !dotu {
+		return b, err
+	}
+	// size[2] also counts the 9P2000.u fields that follow
+	b[i], b[i+1] = byte(n), byte(n>>8)
+	if b, err = pstring(b, d.Extension); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, d.NUID)
+	b = pbit32(b, d.NGID)
+	b = pbit32(b, d.NMUID)
+	return b, nil
 }
@@ -1,33 +1,141 @@
 package plan9
 
 import (
+	"bytes"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/docker/docker/pkg/testutil/assert"
 	"plan9.io"
 )
 
 func Test_unmarshaldir(t *testing.T) {
-	type args struct {
-		b []byte
-		d plan9.Dir
-	}
+	d, record := stat()
+	n := uint16(len(record))
 	tests := []struct {
-		name string
-		args args
+		name      string
+		b         []byte
+		unmarshal func(b []byte, dotu bool) (*plan9.Dir, []byte, error)
+		err       error
 	}{
-		{
-			name: "unmarshaldir",
-			args: func() (a args) {
-				a.d, a.b = stat()
-				return
-			}(),
-		},
+		{"record", record, unmarshaldir, nil},
+		{"stat field", append([]byte{byte(n), byte(n >> 8)}, record...), unmarshalstat, nil},
+		{"stat field with a short count", append([]byte{byte(n - 1), byte(n >> 8)}, record...), unmarshalstat, ErrStatSize},
+		{"stat field with a long count", append([]byte{byte(n + 1), byte(n >> 8)}, record...), unmarshalstat, ErrStatSize},
+		{"stat field without its count", record, unmarshalstat, ErrStatSize},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, _ := unmarshaldir(tt.args.b)
-			assert.DeepEqual(t, *got, tt.args.d)
+			got, rest, err := tt.unmarshal(tt.b, false)
+			assert.Equal(t, err, tt.err)
+			if tt.err == nil {
+				assert.DeepEqual(t, *got, d)
+				assert.Equal(t, len(rest), 0)
+			}
+		})
+	}
+	assert.Equal(t, d.WireSize(), len(record))
+	assert.Equal(t, statsize(&d, false), 2+len(record))
+}
+
+func TestUnmarshalDir(t *testing.T) {
+	d := testDir
+	d.Extension, d.NUID = "/tmp/glenda", 1000
+	for _, dialect := range []plan9.Dialect{plan9.Dialect9P2000, plan9.Dialect9P2000u} {
+		t.Run(dialect.String(), func(t *testing.T) {
+			dotu := Header{dialect: dialect}.dotu()
+			want := d
+			if !dotu {
+				want.Extension, want.NUID = "", 0
+			}
+			b, err := MarshalDir(nil, &d, dialect)
+			assert.NilError(t, err)
+			b, err = MarshalDir(b, &d, dialect)
+			assert.NilError(t, err)
+			got, rest, err := UnmarshalDir(b, dialect)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, &want)
+			got, rest, err = UnmarshalDir(rest, dialect)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, &want)
+			assert.Equal(t, len(rest), 0)
+
+			_, _, err = UnmarshalDir(b[:10], dialect)
+			assert.Equal(t, err, io.ErrUnexpectedEOF)
+		})
+	}
+}
+
+func TestDirMarshalBinary(t *testing.T) {
+	for _, d := range []plan9.Dir{testDir, {}, plan9.NullDir().WithName("renamed")} {
+		t.Run(d.Name, func(t *testing.T) {
+			b, err := d.MarshalBinary()
+			assert.NilError(t, err)
+			p, err := MarshalDir(nil, &d, plan9.Dialect9P2000)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, b, p)
+			got, rest, err := UnmarshalDir(b, plan9.Dialect9P2000)
+			assert.NilError(t, err)
+			assert.Equal(t, len(rest), 0)
+			want := d
+			want.NUID, want.NGID, want.NMUID = 0, 0, 0
+			assert.DeepEqual(t, got, &want)
+		})
+	}
+}
+
+func TestUnmarshalDirs(t *testing.T) {
+	dirs := []plan9.Dir{testDir, {Name: "b"}, {Name: "c", Mode: plan9.DMDIR | 0755}}
+	var b []byte
+	for i := range dirs {
+		var err error
+		b, err = MarshalDir(b, &dirs[i], plan9.Dialect9P2000)
+		assert.NilError(t, err)
+	}
+	got, err := UnmarshalDirs(b)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, dirs)
+	got, err = UnmarshalDirs(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(got), 0)
+	got, err = UnmarshalDirs(b[:len(b)-1])
+	assert.Equal(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, len(got), 2)
+
+	u, err := MarshalDir(nil, &testDir, plan9.Dialect9P2000u)
+	assert.NilError(t, err)
+	got, err = UnmarshalDirsDialect(u, plan9.Dialect9P2000u)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, []plan9.Dir{testDir})
+
+	readers := []struct {
+		name string
+		r    func(b []byte) io.Reader
+	}{
+		{"whole", func(b []byte) io.Reader { return bytes.NewReader(b) }},
+		{"one byte", func(b []byte) io.Reader { return iotest.OneByteReader(bytes.NewReader(b)) }},
+		{"half", func(b []byte) io.Reader { return iotest.HalfReader(bytes.NewReader(b)) }},
+		{"data and EOF", func(b []byte) io.Reader { return iotest.DataErrReader(bytes.NewReader(b)) }},
+	}
+	for _, rt := range readers {
+		t.Run(rt.name, func(t *testing.T) {
+			dr := NewDirReader(rt.r(b), plan9.Dialect9P2000)
+			for i := range dirs {
+				d, err := dr.Next()
+				assert.NilError(t, err)
+				assert.DeepEqual(t, d, &dirs[i])
+			}
+			_, err := dr.Next()
+			assert.Equal(t, err, io.EOF)
+
+			dr = NewDirReader(rt.r(b[:len(b)-1]), plan9.Dialect9P2000)
+			for range dirs[1:] {
+				_, err := dr.Next()
+				assert.NilError(t, err)
+			}
+			_, err = dr.Next()
+			assert.Equal(t, err, io.ErrUnexpectedEOF)
 		})
 	}
 }
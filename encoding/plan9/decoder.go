@@ -7,43 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"reflect"
 
 	"plan9.io"
 )
 
-const (
-	tversion plan9.MessageType = 100 + iota
-	rversion
-	tauth
-	rauth
-	tattach
-	rattach
-	terror
-	rerror
-	tflush
-	rflush
-	twalk
-	rwalk
-	topen
-	ropen
-	tcreate
-	rcreate
-	tread
-	rread
-	twrite
-	rwrite
-	tclunk
-	rclunk
-	tremove
-	rremove
-	tstat
-	rstat
-	twstat
-	rwstat
-	tlast
-)
-
 // Each message consists of a sequence of bytes.
 // Two–, four–, and eight–byte fields hold unsigned
 // integers represented in little–endian order (least significant byte first).
@@ -54,102 +21,158 @@ type Header struct {
 	size  plan9.Size
 	mtype plan9.MessageType
 	tag   plan9.Tag
+
+	dialect plan9.Dialect
+}
+
+// Size is the size of the message in bytes as read from the wire, including the header.
+func (h Header) Size() plan9.Size { return h.size }
+
+// Type is the type of the message.
+func (h Header) Type() plan9.MessageType { return h.mtype }
+
+// Tag is the tag of the message, identifying the request it belongs to.
+func (h Header) Tag() plan9.Tag { return h.tag }
+
+// Dialect is the dialect the message was decoded in, or will be encoded in.
+func (h Header) Dialect() plan9.Dialect { return h.dialect }
+
+// dotu reports whether the optional 9P2000.u fields are present.
+// 9P2000.L keeps them.
+func (h Header) dotu() bool {
+	return h.dialect == plan9.Dialect9P2000u || h.dialect == plan9.Dialect9P2000L
 }
 
 // Decoder decodes 9P messsages.
 type Decoder struct {
-	r   io.Reader
-	msg *Message
+	r       io.Reader
+	buf     []byte
+	msize   uint32
+	dialect plan9.Dialect
 }
 
-func NewDecoder(r io.Reader) *Decoder { return &Decoder{r: r} }
+// NewDecoder returns a Decoder that reads from r, accepting messages up to plan9.MSize.
+func NewDecoder(r io.Reader) *Decoder { return &Decoder{r: r, msize: plan9.MSize} }
+
+// SetMsize sets the maximum message size negotiated with Tversion.
+func (d *Decoder) SetMsize(msize uint32) { d.msize = msize }
+
+// SetDialect sets the dialect negotiated with Tversion.
+func (d *Decoder) SetDialect(dialect plan9.Dialect) { d.dialect = dialect }
+
+// SetSession sets the msize and dialect negotiated with Tversion.
+func (d *Decoder) SetSession(s plan9.Session) { d.msize, d.dialect = s.Msize, s.Dialect }
 
 // Message is a generic 9P message
-type Message interface{ encoding.BinaryUnmarshaler }
+type Message interface {
+	plan9.Message
+	Header() Header
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
 type ProtocolError string
 
 func (e ProtocolError) Error() string {
 	return string(e)
 }
 
-// Decode decodes messages 1by1
+// ErrTrailingData is returned for messages whose size is larger than their fields.
+var ErrTrailingData = ProtocolError("trailing data after message")
+
+// ErrUnknownType is returned by Decode for messages of an unknown or illegal type.
+var ErrUnknownType = ProtocolError("unknown message type")
+
+// UnknownTypeError is returned for a message of an unknown type. It wraps
+// ErrUnknownType. The body of the message is skipped, so the stream may be
+// decoded further.
+type UnknownTypeError struct {
+	Header Header
+}
+
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("9p: %v %d", ErrUnknownType, e.Header.mtype)
+}
+
+func (e *UnknownTypeError) Unwrap() error { return ErrUnknownType }
+
+// DecodeError describes a malformed field in a message.
+type DecodeError struct {
+	Type   plan9.MessageType
+	Offset int    // offset of the field from the start of the message
+	Field  string // name of the field, as in intro(5)
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("9p: decode %v: %s at offset %d: %v", e.Type, e.Field, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// decodeError reports an error decoding field at rest, a suffix of the message body data.
+func decodeError(t plan9.MessageType, field string, data, rest []byte, err error) error {
+	return &DecodeError{Type: t, Offset: 7 + len(data) - len(rest), Field: field, Err: err}
+}
+
+// Decode decodes a single message from r.
 func Decode(r io.Reader) (Message, error) {
-	data := make([]byte, 7)
-	n, err := r.Read(data)
-	if err != nil {
-		return nil, fmt.Errorf("9p: read header: %v", err)
+	return NewDecoder(r).Decode()
+}
+
+// Decode reads the next message from the stream.
+//
+// It returns io.EOF if the stream ends cleanly between messages.
+// The returned message does not retain the decoder's buffer, so it
+// stays valid across calls to Decode.
+func (d *Decoder) Decode() (Message, error) {
+	if cap(d.buf) < 7 {
+		d.buf = make([]byte, 7, 8192)
 	}
-	if n < 7 {
-		return nil, fmt.Errorf("9p: header can't be less than 7 bytes: %v", io.EOF)
+	data := d.buf[:7]
+	if _, err := io.ReadFull(d.r, data); err == io.EOF {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("9p: read header: %w", err)
 	}
-	h := Header{}
+	h := Header{dialect: d.dialect}
 	if err := h.UnmarshalBinary(data); err != nil {
 		return nil, err
 	}
-
+	if h.size < 7 {
+		return nil, fmt.Errorf("9p: header can't be less than 7 bytes: size %d", h.size)
+	}
+	if uint32(h.size) > d.msize {
+		return nil, fmt.Errorf("9p: size %d: %w", h.size, ErrMsgTooLarge)
+	}
+	n := int(h.size) - 7
+	if cap(d.buf) < n {
+		d.buf = make([]byte, n)
+	}
+	data = d.buf[:n]
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("9p: read body: %w", err)
+	}
 	msg := newMessage(h)
-	data = make([]byte, h.size)
-
-	n, err = r.Read(data)
-	if err == io.EOF && n == int(h.size)-7 {
-		return msg, nil
-	} else if err != nil {
-		return nil, fmt.Errorf("9p: read body: %v", err)
+	if msg == nil {
+		return nil, &UnknownTypeError{Header: h}
 	}
-	if n < int(h.size)-7 {
-		return nil, fmt.Errorf("9p: header can't be less than 7 bytes: %v", io.EOF)
+	if err := msg.UnmarshalBinary(data); err != nil {
+		return nil, err
 	}
-	msg.UnmarshalBinary(data)
 	return msg, nil
 }
 
 func (h *Header) UnmarshalBinary(data []byte) error {
+	if len(data) < 7 {
+		return fmt.Errorf("9p: header can't be less than 7 bytes: %w", io.ErrUnexpectedEOF)
+	}
 	h.size = plan9.Size(data[0]) | plan9.Size(data[1])<<8 | plan9.Size(data[2])<<16 | plan9.Size(data[3])<<24
 	h.mtype = plan9.MessageType(data[4])
 	h.tag = plan9.Tag(uint16(data[5]) | uint16(data[6])<<8)
 	return nil
 }
 
-func (d *Decoder) unmarshall(x interface{}) error {
-	v := reflect.ValueOf(x)
-	s := v.Elem()
-	st := s.Type()
-	for i := 0; i < st.NumField(); i++ {
-		var b []byte
-		v := st.FieldByIndex([]int{i})
-		sz := v.Type.Size()
-		f := s.FieldByIndex([]int{i})
-		if sz > 8 {
-			goto SKIP
-		}
-		b = make([]byte, sz)
-		d.r.Read(b)
-	SKIP:
-		switch sz {
-		case 1:
-			f.SetUint(uint64(b[0]))
-		case 2:
-			f.SetUint(uint64(encoder.Uint16(b)))
-		case 4:
-			f.SetUint(uint64(encoder.Uint32(b)))
-		case 8:
-			f.SetUint(encoder.Uint64(b))
-		default:
-			b = make([]byte, 2)
-			d.r.Read(b)
-			b = make([]byte, encoder.Uint16(b))
-			d.r.Read(b)
-			f.SetString(string(b))
-		}
-	}
-	return nil
-}
-
-func unmarshallstring(data *[]byte, s *string) uint16 {
-	size := uint16((*data)[0]) | uint16((*data)[1])<<8
-	*s = string((*data)[2 : 2+size])
-	*data = (*data)[2+size:]
-	return size
-}
-
 var ErrStringMalformed = errors.New("string malformed")
@@ -2,8 +2,11 @@ package plan9
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"log"
 	"math/rand"
+	"testing/iotest"
 	"time"
 
 	"testing"
@@ -37,7 +40,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &VersionReq{
 						header: Header{
-							mtype: tversion,
+							mtype: plan9.Tversion,
 							tag:   plan9.Tag(styxproto.NoTag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -59,7 +62,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &VersionResp{
 						header: Header{
-							mtype: rversion,
+							mtype: plan9.Rversion,
 							tag:   plan9.Tag(styxproto.NoTag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -91,7 +94,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &AuthReq{
 						header: Header{
-							mtype: tauth,
+							mtype: plan9.Tauth,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -124,7 +127,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &AuthResp{
 						header: Header{
-							mtype: rauth,
+							mtype: plan9.Rauth,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -150,7 +153,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &ErrorResp{
 						header: Header{
-							mtype: rerror,
+							mtype: plan9.Rerror,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -178,7 +181,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &FlushReq{
 						header: Header{
-							mtype: tflush,
+							mtype: plan9.Tflush,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -206,7 +209,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &FlushResp{
 						header: Header{
-							mtype: rflush,
+							mtype: plan9.Rflush,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -237,7 +240,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &AttachReq{
 						header: Header{
-							mtype: tattach,
+							mtype: plan9.Tattach,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -273,7 +276,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &AttachResp{
 						header: Header{
-							mtype: rattach,
+							mtype: plan9.Rattach,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -308,7 +311,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &WalkReq{
 						header: Header{
-							mtype: twalk,
+							mtype: plan9.Twalk,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -353,7 +356,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &WalkResp{
 						header: Header{
-							mtype: rwalk,
+							mtype: plan9.Rwalk,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -381,7 +384,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &OpenReq{
 						header: Header{
-							mtype: topen,
+							mtype: plan9.Topen,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -418,7 +421,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &OpenResp{
 						header: Header{
-							mtype: ropen,
+							mtype: plan9.Ropen,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -454,7 +457,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &CreateReq{
 						header: Header{
-							mtype: tcreate,
+							mtype: plan9.Tcreate,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -493,7 +496,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &CreateResp{
 						header: Header{
-							mtype: rcreate,
+							mtype: plan9.Rcreate,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -527,7 +530,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &ReadReq{
 						header: Header{
-							mtype: tread,
+							mtype: plan9.Tread,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -564,7 +567,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &ReadResp{
 						header: Header{
-							mtype: rread,
+							mtype: plan9.Rread,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -597,7 +600,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &WriteReq{
 						header: Header{
-							mtype: twrite,
+							mtype: plan9.Twrite,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -634,7 +637,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &WriteResp{
 						header: Header{
-							mtype: rwrite,
+							mtype: plan9.Rwrite,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -669,7 +672,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &ClunkReq{
 						header: Header{
-							mtype: tclunk,
+							mtype: plan9.Tclunk,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -698,7 +701,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &ClunkResp{
 						header: Header{
-							mtype: rclunk,
+							mtype: plan9.Rclunk,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -725,7 +728,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &RemoveReq{
 						header: Header{
-							mtype: tremove,
+							mtype: plan9.Tremove,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -751,7 +754,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &RemoveResp{
 						header: Header{
-							mtype: rremove,
+							mtype: plan9.Rremove,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -777,7 +780,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &StatReq{
 						header: Header{
-							mtype: tstat,
+							mtype: plan9.Tstat,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -801,7 +804,7 @@ func TestDecoder_Decode(t *testing.T) {
 					buf: b.Bytes(),
 					want: &StatResp{
 						header: Header{
-							mtype: rstat,
+							mtype: plan9.Rstat,
 							tag:   plan9.Tag(tag),
 							size:  plan9.Size(b.Len()),
 						},
@@ -828,27 +831,138 @@ func TestDecoder_Decode(t *testing.T) {
 	}
 }
 
-func BenchmarkHardcoded(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		buf := bytes.NewBuffer([]byte{19, 0, 0, 0, 100, 0x55, 0xaa, 0, 32, 0, 0, 6, 0, 57, 80, 50, 48, 48, 48})
-		_, _ = Decode(buf)
+func TestDecoder_DecodeStream(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	var want []Message
+	for i := 0; i < 3000; i++ {
+		tag := plan9.Tag(i)
+		data := bytes.Repeat([]byte{byte(i)}, 1+i%100)
+		enc.Twrite(tag, 1, uint64(i), data)
+		enc.Rwalk(tag, []plan9.QID{testQID})
+		want = append(want,
+			&WriteReq{header: Header{size: plan9.Size(23 + len(data)), mtype: plan9.Twrite, tag: tag}, fid: 1, offset: uint64(i), data: data},
+			&WalkResp{header: Header{size: 22, mtype: plan9.Rwalk, tag: tag}, wqid: []plan9.QID{testQID}},
+		)
+	}
+	enc.Flush()
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"Full":    func(r io.Reader) io.Reader { return r },
+		"OneByte": iotest.OneByteReader,
+		"Half":    iotest.HalfReader,
+		"DataErr": iotest.DataErrReader,
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			dec := NewDecoder(reader(bytes.NewReader(b.Bytes())))
+			for i, w := range want {
+				msg, err := dec.Decode()
+				if err != nil {
+					t.Fatalf("message %d: %v", i, err)
+				}
+				assert.DeepEqual(t, msg, w)
+			}
+			_, err := dec.Decode()
+			assert.Equal(t, err, io.EOF)
+		})
 	}
 }
 
-type versionReq struct {
-	Size     plan9.Size        // 4 bytes
-	Tversion plan9.MessageType // 1 byte
-	Tag      plan9.Tag         // 2 bytes
-	MaxSize  uint32            // 4 bytes
-	Version  string            // 8 bytes (almost definitely probably)
+func TestDecoder_DecodeTruncated(t *testing.T) {
+	b, _ := (&WalkResp{header: Header{tag: 1}, wqid: []plan9.QID{testQID}}).MarshalBinary()
+	for _, n := range []int{3, 7, len(b) - 1} {
+		_, err := NewDecoder(bytes.NewReader(b[:n])).Decode()
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("Decode() of %d bytes: error = %v, want %v", n, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestDecoder_DecodeUnknownType(t *testing.T) {
+	for _, mtype := range []plan9.MessageType{plan9.Terror, 99, 200} {
+		t.Run(mtype.String(), func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+			b.Write([]byte{11, 0, 0, 0, byte(mtype), 1, 0, 0xde, 0xad, 0xbe, 0xef})
+			enc := NewEncoder(b)
+			enc.Rclunk(2)
+			enc.Flush()
+
+			dec := NewDecoder(b)
+			_, err := dec.Decode()
+			if !errors.Is(err, ErrUnknownType) {
+				t.Fatalf("Decode() error = %v, want ErrUnknownType", err)
+			}
+			var uerr *UnknownTypeError
+			if !errors.As(err, &uerr) {
+				t.Fatalf("Decode() error = %T, want *UnknownTypeError", err)
+			}
+			assert.Equal(t, uerr.Header, Header{size: 11, mtype: mtype, tag: 1})
+
+			msg, err := dec.Decode()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, msg, &ClunkResp{header: Header{size: 7, mtype: plan9.Rclunk, tag: 2}})
+		})
+	}
 }
 
-func BenchmarkReflected(b *testing.B) {
+func TestDecoder_DecodeTrailingData(t *testing.T) {
+	for _, b := range [][]byte{
+		{8, 0, 0, 0, byte(plan9.Rclunk), 1, 0, 0},
+		{12, 0, 0, 0, byte(plan9.Tclunk), 1, 0, 1, 0, 0, 0, 0},
+	} {
+		_, err := Decode(bytes.NewReader(b))
+		var derr *DecodeError
+		if !errors.As(err, &derr) || derr.Err != ErrTrailingData {
+			t.Fatalf("Decode(%v) error = %v, want ErrTrailingData", b, err)
+		}
+		assert.Equal(t, derr.Field, "size")
+		assert.Equal(t, derr.Offset, len(b)-1)
+	}
+}
+
+func TestDecoder_DecodeSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		buf   []byte
+		msize uint32
+		want  error
+	}{
+		{"TooSmall", []byte{6, 0, 0, 0, byte(plan9.Rclunk), 1, 0}, plan9.MSize, nil},
+		{"Huge", []byte{0xff, 0xff, 0xff, 0xff, byte(plan9.Rread), 1, 0}, plan9.MSize, ErrMsgTooLarge},
+		{"Msize", []byte{12, 0, 0, 0, byte(plan9.Tclunk), 1, 0, 1, 0, 0, 0}, 11, ErrMsgTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(bytes.NewReader(tt.buf))
+			dec.SetMsize(tt.msize)
+			_, err := dec.Decode()
+			if err == nil {
+				t.Fatal("Decode() error = nil")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	m, _ := (&ReadResp{header: Header{tag: 1}, data: make([]byte, 4096)}).MarshalBinary()
+	r := bytes.NewReader(m)
+	dec := NewDecoder(r)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Reset(m)
+		if _, err := dec.Decode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHardcoded(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		buf := bytes.NewBuffer([]byte{19, 0, 0, 0, 100, 0x55, 0xaa, 0, 32, 0, 0, 6, 0, 57, 80, 50, 48, 48, 48})
-		d := Decoder{r: buf}
-		_ = d
-		msg := &versionReq{}
-		d.unmarshall(msg)
+		_, _ = Decode(buf)
 	}
 }
@@ -0,0 +1,85 @@
+package plan9
+
+import (
+	"io"
+
+	"plan9.io"
+)
+
+// UnmarshalDir decodes the stat record at the start of b, as found in the
+// data of a directory read, and returns the bytes following it. The
+// 9P2000.u fields are decoded if the dialect has them.
+func UnmarshalDir(b []byte, dialect plan9.Dialect) (*plan9.Dir, []byte, error) {
+	return unmarshaldir(b, Header{dialect: dialect}.dotu())
+}
+
+// MarshalDir appends the stat record of d, as found in the data of a
+// directory read, to b.
+func MarshalDir(b []byte, d *plan9.Dir, dialect plan9.Dialect) ([]byte, error) {
+	return marshaldir(b, d, Header{dialect: dialect}.dotu())
+}
+
+// UnmarshalDirs decodes the 9P2000 stat records making up data, the data
+// of a directory read.
+func UnmarshalDirs(data []byte) ([]plan9.Dir, error) {
+	return UnmarshalDirsDialect(data, plan9.Dialect9P2000)
+}
+
+// UnmarshalDirsDialect is UnmarshalDirs for the stat records of dialect,
+// with the 9P2000.u fields if it has them.
+func UnmarshalDirsDialect(data []byte, dialect plan9.Dialect) ([]plan9.Dir, error) {
+	var dirs []plan9.Dir
+	b := data
+	for len(b) > 0 {
+		d, rest, err := UnmarshalDir(b, dialect)
+		if err != nil {
+			return dirs, err
+		}
+		dirs = append(dirs, *d)
+		b = rest
+	}
+	return dirs, nil
+}
+
+// dirReadSize is the most a DirReader asks for in one read.
+const dirReadSize = 8192
+
+// A DirReader decodes the stat records read from a directory, such as an
+// open client File, one at a time. Records split across reads are joined.
+type DirReader struct {
+	r       io.Reader
+	dialect plan9.Dialect
+	buf     []byte // read but not decoded
+	p       []byte // for reading into
+	err     error  // the error of the last read
+}
+
+// NewDirReader returns a DirReader reading from r the stat records of
+// dialect.
+func NewDirReader(r io.Reader, dialect plan9.Dialect) *DirReader {
+	return &DirReader{r: r, dialect: dialect}
+}
+
+// Next returns the next directory entry, or io.EOF after the last. A
+// partial record at the end is reported as io.ErrUnexpectedEOF.
+func (dr *DirReader) Next() (*plan9.Dir, error) {
+	for {
+		if size, _, err := guint16(dr.buf); err == nil && len(dr.buf) >= 2+int(size) {
+			d, _, err := UnmarshalDir(dr.buf[:2+int(size)], dr.dialect)
+			dr.buf = dr.buf[2+int(size):]
+			return d, err
+		}
+		if dr.err != nil {
+			if dr.err == io.EOF && len(dr.buf) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, dr.err
+		}
+		if dr.p == nil {
+			dr.p = make([]byte, dirReadSize)
+		}
+		n, err := dr.r.Read(dr.p)
+		dr.buf = append(dr.buf, dr.p[:n]...)
+		dr.err = err
+	}
+}
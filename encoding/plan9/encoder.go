@@ -0,0 +1,65 @@
+package plan9
+
+import (
+	"bufio"
+	"io"
+
+	"plan9.io"
+)
+
+// ErrMsgTooLarge is returned when a message does not fit in the msize.
+var ErrMsgTooLarge = ProtocolError("message larger than msize")
+
+// Encoder encodes 9P messages.
+//
+// Messages are buffered, call Flush to write them to the underlying writer.
+type Encoder struct {
+	w       *bufio.Writer
+	buf     []byte
+	msize   uint32
+	dialect plan9.Dialect
+}
+
+// NewEncoder returns an Encoder that writes to w, limiting messages to plan9.MSize.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), msize: plan9.MSize}
+}
+
+// SetMsize sets the maximum message size negotiated with Tversion.
+func (e *Encoder) SetMsize(msize uint32) { e.msize = msize }
+
+// SetDialect sets the dialect negotiated with Tversion.
+// It applies to messages written with the Encoder's per-message methods.
+func (e *Encoder) SetDialect(dialect plan9.Dialect) { e.dialect = dialect }
+
+// SetSession sets the msize and dialect negotiated with Tversion.
+func (e *Encoder) SetSession(s plan9.Session) { e.msize, e.dialect = s.Msize, s.Dialect }
+
+// Encode writes m.
+func (e *Encoder) Encode(m Message) error {
+	if a, ok := m.(interface{ appendBinary([]byte) ([]byte, error) }); ok {
+		b, err := a.appendBinary(e.buf[:0])
+		if err != nil {
+			return err
+		}
+		e.buf = b
+	} else {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		e.buf = append(e.buf[:0], b...)
+	}
+	return e.write()
+}
+
+// Flush writes any buffered messages to the underlying writer.
+func (e *Encoder) Flush() error { return e.w.Flush() }
+
+func (e *Encoder) write() error {
+	if uint32(len(e.buf)) > e.msize {
+		return ErrMsgTooLarge
+	}
+	_, err := e.w.Write(e.buf)
+	return err
+}
@@ -0,0 +1,122 @@
+package plan9
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+)
+
+func TestEncoder(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	assert.NilError(t, enc.Tversion(plan9.NoTag, 8192, plan9.DefaultVersion))
+	assert.NilError(t, enc.Twalk(1, 1, 2, []string{"usr", "glenda"}))
+	assert.NilError(t, enc.Rwalk(1, []plan9.QID{testQID, testQID}))
+	assert.NilError(t, enc.Rread(1, []byte("hello")))
+	assert.NilError(t, enc.Rstat(1, &testDir))
+	assert.NilError(t, enc.Encode(&WriteResp{header: Header{tag: 1}, count: 5}))
+	assert.Equal(t, b.Len(), 0)
+	assert.NilError(t, enc.Flush())
+
+	want := bytes.NewBuffer(nil)
+	for _, msg := range []Message{
+		&VersionReq{header: Header{tag: plan9.NoTag}, msize: 8192, version: plan9.DefaultVersion},
+		&WalkReq{header: Header{tag: 1}, fid: 1, newfid: 2, wname: []string{"usr", "glenda"}},
+		&WalkResp{header: Header{tag: 1}, wqid: []plan9.QID{testQID, testQID}},
+		&ReadResp{header: Header{tag: 1}, data: []byte("hello")},
+		&StatResp{header: Header{tag: 1}, stat: &testDir},
+		&WriteResp{header: Header{tag: 1}, count: 5},
+	} {
+		p, err := msg.MarshalBinary()
+		assert.NilError(t, err)
+		want.Write(p)
+	}
+	assert.DeepEqual(t, b.Bytes(), want.Bytes())
+}
+
+func TestEncoderDialect(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	enc.SetDialect(plan9.Dialect9P2000u)
+	assert.NilError(t, enc.Tattach(1, 1, plan9.NoFID, "glenda", "", 1000))
+	assert.NilError(t, enc.Flush())
+
+	dec := NewDecoder(b)
+	dec.SetDialect(plan9.Dialect9P2000u)
+	msg, err := dec.Decode()
+	assert.NilError(t, err)
+	attach := msg.(*AttachReq)
+	assert.Equal(t, attach.Header().Dialect(), plan9.Dialect9P2000u)
+	assert.Equal(t, attach.NUname(), uint32(1000))
+}
+
+func TestEncoderMsize(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	enc.SetMsize(8192)
+	assert.NilError(t, enc.Rread(1, make([]byte, 8192-11)))
+	assert.Equal(t, enc.Rread(1, make([]byte, 8192-10)), ErrMsgTooLarge)
+	assert.NilError(t, enc.Flush())
+	assert.Equal(t, b.Len(), 8192)
+}
+
+func TestEncoderStringTooLong(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	enc.SetMsize(1 << 20)
+	long := strings.Repeat("x", 1<<16)
+	assert.Equal(t, enc.Twalk(1, 1, 2, []string{"usr", long}), ErrStringTooLong)
+	assert.Equal(t, enc.Rerror(1, long, 0), ErrStringTooLong)
+	_, err := (&WalkReq{wname: []string{long}}).MarshalBinary()
+	assert.Equal(t, err, ErrStringTooLong)
+	assert.NilError(t, enc.Flush())
+	assert.Equal(t, b.Len(), 0)
+}
+
+func TestEncoderStatTooLarge(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	enc.SetMsize(1 << 20)
+	long := strings.Repeat("x", 1<<16)
+	d := testDir
+	d.Name = long
+	assert.Equal(t, enc.Rstat(1, &d), ErrStatTooLarge)
+	// a record of 0xffff bytes fits its size[2], but not the count n[2]
+	// of a stat[n] field holding it as well
+	d = plan9.Dir{Name: long[:0xffff-47]}
+	_, err := MarshalDir(nil, &d, plan9.Dialect9P2000)
+	assert.NilError(t, err)
+	assert.Equal(t, enc.Rstat(1, &d), ErrStatTooLarge)
+	d.Name, d.UID = long[:0x8000], long[:0x8000]
+	_, err = MarshalDir(nil, &d, plan9.Dialect9P2000)
+	assert.Equal(t, err, ErrStatTooLarge)
+	assert.NilError(t, enc.Flush())
+	assert.Equal(t, b.Len(), 0)
+}
+
+func TestEncoderAllocs(t *testing.T) {
+	enc := NewEncoder(ioutil.Discard)
+	data := make([]byte, 4096)
+	d := &plan9.Dir{Name: "lib", UID: "glenda", GID: "glenda", Muid: "glenda", Mode: plan9.DMDIR | 0775}
+	tests := []struct {
+		name  string
+		write func() error
+	}{
+		{"Rread", func() error { return enc.Rread(1, data) }},
+		{"Rstat", func() error { return enc.Rstat(1, d) }},
+		{"Twstat", func() error { return enc.Twstat(1, 1, d) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(100, func() {
+				assert.NilError(t, tt.write())
+				enc.Flush()
+			})
+			assert.Equal(t, allocs, float64(0))
+		})
+	}
+}
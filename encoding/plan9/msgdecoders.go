@@ -12,12 +12,53 @@ type VersionReq struct {
 	version string
 }
 
+// Header returns the header of the message.
+func (v *VersionReq) Header() Header { return v.header }
+
+// Msize returns the msize field of the message.
+func (v *VersionReq) Msize() uint32 { return v.msize }
+
+// Version returns the version field of the message.
+func (v *VersionReq) Version() string { return v.version }
+
 func (v *VersionReq) UnmarshalBinary(data []byte) error {
-	v.msize, data = guint32(data)
-	v.version, data = gstring(data)
+	var err error
+	b := data
+	if v.msize, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tversion, "msize", data, b, err)
+	}
+	if v.version, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tversion, "version", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tversion, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (v *VersionReq) Size() plan9.Size {
+	n := 13
+	n += len(v.version)
+	return plan9.Size(n)
+}
+
+func (v *VersionReq) MarshalBinary() ([]byte, error) {
+	return v.appendBinary(make([]byte, 0, v.Size()))
+}
+
+func (v *VersionReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tversion, v.header.tag)
+	b = pbit32(b, v.msize)
+	if b, err = pstring(b, v.version); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // VersionResp is a 9P Rversion message
 //
 // 	size[4] Rversion tag[2] msize[4] version[s]
@@ -28,30 +69,136 @@ type VersionResp struct {
 	version string
 }
 
+// Header returns the header of the message.
+func (v *VersionResp) Header() Header { return v.header }
+
+// Msize returns the msize field of the message.
+func (v *VersionResp) Msize() uint32 { return v.msize }
+
+// Version returns the version field of the message.
+func (v *VersionResp) Version() string { return v.version }
+
 func (v *VersionResp) UnmarshalBinary(data []byte) error {
-	v.msize, data = guint32(data)
-	v.version, data = gstring(data)
+	var err error
+	b := data
+	if v.msize, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rversion, "msize", data, b, err)
+	}
+	if v.version, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Rversion, "version", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rversion, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (v *VersionResp) Size() plan9.Size {
+	n := 13
+	n += len(v.version)
+	return plan9.Size(n)
+}
+
+func (v *VersionResp) MarshalBinary() ([]byte, error) {
+	return v.appendBinary(make([]byte, 0, v.Size()))
+}
+
+func (v *VersionResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Rversion, v.header.tag)
+	b = pbit32(b, v.msize)
+	if b, err = pstring(b, v.version); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // AuthReq is a 9P Tauth message
 //
-// 	size[4] Tauth tag[2] afid[4] uname[s] aname[s]
+// 	size[4] Tauth tag[2] afid[4] uname[s] aname[s] .u n_uname[4]
 //
 type AuthReq struct {
 	header Header
 	afid   plan9.FID
 	uname  string
 	aname  string
+	nUname uint32
 }
 
+// Header returns the header of the message.
+func (a *AuthReq) Header() Header { return a.header }
+
+// Afid returns the afid field of the message.
+func (a *AuthReq) Afid() plan9.FID { return a.afid }
+
+// Uname returns the uname field of the message.
+func (a *AuthReq) Uname() string { return a.uname }
+
+// Aname returns the aname field of the message.
+func (a *AuthReq) Aname() string { return a.aname }
+
+// NUname returns the nUname field of the message.
+func (a *AuthReq) NUname() uint32 { return a.nUname }
+
 func (a *AuthReq) UnmarshalBinary(data []byte) error {
-	a.afid, data = gfid(data)
-	a.uname, data = gstring(data)
-	a.aname, data = gstring(data)
+	var err error
+	b := data
+	if a.afid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tauth, "afid", data, b, err)
+	}
+	if a.uname, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tauth, "uname", data, b, err)
+	}
+	if a.aname, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tauth, "aname", data, b, err)
+	}
+	if a.header.dotu() {
+		if a.nUname, b, err = guint32(b); err != nil {
+			return decodeError(plan9.Tauth, "nUname", data, b, err)
+		}
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tauth, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (a *AuthReq) Size() plan9.Size {
+	n := 15
+	n += len(a.uname)
+	n += len(a.aname)
+	if a.header.dotu() {
+		n += 4
+	}
+	return plan9.Size(n)
+}
+
+func (a *AuthReq) MarshalBinary() ([]byte, error) {
+	return a.appendBinary(make([]byte, 0, a.Size()))
+}
+
+func (a *AuthReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tauth, a.header.tag)
+	b = pfid(b, a.afid)
+	if b, err = pstring(b, a.uname); err != nil {
+		return nil, err
+	}
+	if b, err = pstring(b, a.aname); err != nil {
+		return nil, err
+	}
+	if a.header.dotu() {
+		b = pbit32(b, a.nUname)
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // AuthResp is a 9P Rauth message
 //
 // 	size[4] Rauth tag[2] aqid[13]
@@ -61,27 +208,105 @@ type AuthResp struct {
 	aqid   plan9.QID
 }
 
+// Header returns the header of the message.
+func (a *AuthResp) Header() Header { return a.header }
+
+// Aqid returns the aqid field of the message.
+func (a *AuthResp) Aqid() plan9.QID { return a.aqid }
+
 func (a *AuthResp) UnmarshalBinary(data []byte) error {
-	a.aqid.Type, data = guint8(data)
-	a.aqid.Vers, data = guint32(data)
-	a.aqid.Path, data = guint64(data)
+	var err error
+	b := data
+	if a.aqid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rauth, "aqid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rauth, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (a *AuthResp) Size() plan9.Size {
+	return 20
+}
+
+func (a *AuthResp) MarshalBinary() ([]byte, error) {
+	return a.appendBinary(make([]byte, 0, a.Size()))
+}
+
+func (a *AuthResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rauth, a.header.tag)
+	b = pqid(b, a.aqid)
+	psize(b[n:])
+	return b, nil
+}
+
 // ErrorResp is a 9P Rerror message
 //
-// 	size[4] Rerror tag[2] ename[s]
+// 	size[4] Rerror tag[2] ename[s] .u errno[4]
 //
 type ErrorResp struct {
 	header Header
 	ename  string
+	errno  uint32
 }
 
+// Header returns the header of the message.
+func (e *ErrorResp) Header() Header { return e.header }
+
+// Ename returns the ename field of the message.
+func (e *ErrorResp) Ename() string { return e.ename }
+
+// Errno returns the errno field of the message.
+func (e *ErrorResp) Errno() uint32 { return e.errno }
+
 func (e *ErrorResp) UnmarshalBinary(data []byte) error {
-	e.ename, data = gstring(data)
+	var err error
+	b := data
+	if e.ename, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Rerror, "ename", data, b, err)
+	}
+	if e.header.dotu() {
+		if e.errno, b, err = guint32(b); err != nil {
+			return decodeError(plan9.Rerror, "errno", data, b, err)
+		}
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rerror, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (e *ErrorResp) Size() plan9.Size {
+	n := 9
+	n += len(e.ename)
+	if e.header.dotu() {
+		n += 4
+	}
+	return plan9.Size(n)
+}
+
+func (e *ErrorResp) MarshalBinary() ([]byte, error) {
+	return e.appendBinary(make([]byte, 0, e.Size()))
+}
+
+func (e *ErrorResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Rerror, e.header.tag)
+	if b, err = pstring(b, e.ename); err != nil {
+		return nil, err
+	}
+	if e.header.dotu() {
+		b = pbit32(b, e.errno)
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // FlushReq is a 9P Tflush message
 //
 // 	size[4] Tflush tag[2] oldtag[2]
@@ -91,11 +316,41 @@ type FlushReq struct {
 	oldtag plan9.Tag
 }
 
+// Header returns the header of the message.
+func (f *FlushReq) Header() Header { return f.header }
+
+// Oldtag returns the oldtag field of the message.
+func (f *FlushReq) Oldtag() plan9.Tag { return f.oldtag }
+
 func (f *FlushReq) UnmarshalBinary(data []byte) error {
-	f.oldtag, data = gtag(data)
+	var err error
+	b := data
+	if f.oldtag, b, err = gtag(b); err != nil {
+		return decodeError(plan9.Tflush, "oldtag", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tflush, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (f *FlushReq) Size() plan9.Size {
+	return 9
+}
+
+func (f *FlushReq) MarshalBinary() ([]byte, error) {
+	return f.appendBinary(make([]byte, 0, f.Size()))
+}
+
+func (f *FlushReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tflush, f.header.tag)
+	b = ptag(b, f.oldtag)
+	psize(b[n:])
+	return b, nil
+}
+
 // FlushResp is a 9P Rflush message
 //
 // 	size[4] Rflush tag[2]
@@ -104,13 +359,35 @@ type FlushResp struct {
 	header Header
 }
 
+// Header returns the header of the message.
+func (f *FlushResp) Header() Header { return f.header }
+
 func (f *FlushResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rflush, "size", data, data, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (f *FlushResp) Size() plan9.Size {
+	return 7
+}
+
+func (f *FlushResp) MarshalBinary() ([]byte, error) {
+	return f.appendBinary(make([]byte, 0, f.Size()))
+}
+
+func (f *FlushResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rflush, f.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
 // AttachReq is a 9P Tattach message
 //
-// 	size[4] Tattach tag[2] fid[4] afid[4] uname[s] aname[s]
+// 	size[4] Tattach tag[2] fid[4] afid[4] uname[s] aname[s] .u n_uname[4]
 //
 type AttachReq struct {
 	header Header
@@ -118,16 +395,87 @@ type AttachReq struct {
 	afid   plan9.FID
 	uname  string
 	aname  string
+	nUname uint32
 }
 
+// Header returns the header of the message.
+func (a *AttachReq) Header() Header { return a.header }
+
+// Fid returns the fid field of the message.
+func (a *AttachReq) Fid() plan9.FID { return a.fid }
+
+// Afid returns the afid field of the message.
+func (a *AttachReq) Afid() plan9.FID { return a.afid }
+
+// Uname returns the uname field of the message.
+func (a *AttachReq) Uname() string { return a.uname }
+
+// Aname returns the aname field of the message.
+func (a *AttachReq) Aname() string { return a.aname }
+
+// NUname returns the nUname field of the message.
+func (a *AttachReq) NUname() uint32 { return a.nUname }
+
 func (a *AttachReq) UnmarshalBinary(data []byte) error {
-	a.fid, data = gfid(data)
-	a.afid, data = gfid(data)
-	a.uname, data = gstring(data)
-	a.aname, data = gstring(data)
+	var err error
+	b := data
+	if a.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tattach, "fid", data, b, err)
+	}
+	if a.afid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tattach, "afid", data, b, err)
+	}
+	if a.uname, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tattach, "uname", data, b, err)
+	}
+	if a.aname, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tattach, "aname", data, b, err)
+	}
+	if a.header.dotu() {
+		if a.nUname, b, err = guint32(b); err != nil {
+			return decodeError(plan9.Tattach, "nUname", data, b, err)
+		}
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tattach, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (a *AttachReq) Size() plan9.Size {
+	n := 19
+	n += len(a.uname)
+	n += len(a.aname)
+	if a.header.dotu() {
+		n += 4
+	}
+	return plan9.Size(n)
+}
+
+func (a *AttachReq) MarshalBinary() ([]byte, error) {
+	return a.appendBinary(make([]byte, 0, a.Size()))
+}
+
+func (a *AttachReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tattach, a.header.tag)
+	b = pfid(b, a.fid)
+	b = pfid(b, a.afid)
+	if b, err = pstring(b, a.uname); err != nil {
+		return nil, err
+	}
+	if b, err = pstring(b, a.aname); err != nil {
+		return nil, err
+	}
+	if a.header.dotu() {
+		b = pbit32(b, a.nUname)
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // AttachResp is a 9P Rattach message
 //
 // 	size[4] Rattach tag[2] qid[13]
@@ -137,13 +485,41 @@ type AttachResp struct {
 	qid    plan9.QID
 }
 
+// Header returns the header of the message.
+func (a *AttachResp) Header() Header { return a.header }
+
+// Qid returns the qid field of the message.
+func (a *AttachResp) Qid() plan9.QID { return a.qid }
+
 func (a *AttachResp) UnmarshalBinary(data []byte) error {
-	a.qid.Type, data = guint8(data)
-	a.qid.Vers, data = guint32(data)
-	a.qid.Path, data = guint64(data)
+	var err error
+	b := data
+	if a.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rattach, "qid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rattach, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (a *AttachResp) Size() plan9.Size {
+	return 20
+}
+
+func (a *AttachResp) MarshalBinary() ([]byte, error) {
+	return a.appendBinary(make([]byte, 0, a.Size()))
+}
+
+func (a *AttachResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rattach, a.header.tag)
+	b = pqid(b, a.qid)
+	psize(b[n:])
+	return b, nil
+}
+
 // WalkReq is a 9P Twalk message
 //
 // 	size[4] Twalk tag[2] fid[4] newfid[4] nwname[2] nwname*(wname[s])
@@ -155,19 +531,73 @@ type WalkReq struct {
 	wname  []string
 }
 
+// Header returns the header of the message.
+func (w *WalkReq) Header() Header { return w.header }
+
+// Fid returns the fid field of the message.
+func (w *WalkReq) Fid() plan9.FID { return w.fid }
+
+// Newfid returns the newfid field of the message.
+func (w *WalkReq) Newfid() plan9.FID { return w.newfid }
+
+// Wname returns the wname field of the message.
+func (w *WalkReq) Wname() []string { return w.wname }
+
 func (w *WalkReq) UnmarshalBinary(data []byte) error {
-	w.fid, data = gfid(data)
-	w.newfid, data = gfid(data)
-	var nwname uint16
-	nwname, data = guint16(data)
-	for i := uint16(0); i < nwname; i++ {
-		var s string
-		s, data = gstring(data)
-		w.wname = append(w.wname, s)
+	var err error
+	b := data
+	if w.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Twalk, "fid", data, b, err)
+	}
+	if w.newfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Twalk, "newfid", data, b, err)
+	}
+	var n uint16
+	if n, b, err = guint16(b); err != nil {
+		return decodeError(plan9.Twalk, "nwname", data, b, err)
+	}
+	for i := uint16(0); i < n; i++ {
+		var x string
+		if x, b, err = gstring(b); err != nil {
+			return decodeError(plan9.Twalk, "wname", data, b, err)
+		}
+		w.wname = append(w.wname, x)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Twalk, "size", data, b, ErrTrailingData)
 	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (w *WalkReq) Size() plan9.Size {
+	n := 17
+	for _, x := range w.wname {
+		n += 2 + len(x)
+	}
+	return plan9.Size(n)
+}
+
+func (w *WalkReq) MarshalBinary() ([]byte, error) {
+	return w.appendBinary(make([]byte, 0, w.Size()))
+}
+
+func (w *WalkReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Twalk, w.header.tag)
+	b = pfid(b, w.fid)
+	b = pfid(b, w.newfid)
+	b = pbit16(b, uint16(len(w.wname)))
+	for _, x := range w.wname {
+		if b, err = pstring(b, x); err != nil {
+			return nil, err
+		}
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // WalkResp is a 9P Rwalk message
 //
 // 	size[4] Rwalk tag[2] nwqid[2] nwqid*(wqid[13])
@@ -177,19 +607,54 @@ type WalkResp struct {
 	wqid   []plan9.QID
 }
 
+// Header returns the header of the message.
+func (w *WalkResp) Header() Header { return w.header }
+
+// Wqid returns the wqid field of the message.
+func (w *WalkResp) Wqid() []plan9.QID { return w.wqid }
+
 func (w *WalkResp) UnmarshalBinary(data []byte) error {
-	var nwname uint16
-	nwname, data = guint16(data)
-	for i := uint16(0); i < nwname; i++ {
-		var q plan9.QID
-		q.Type, data = guint8(data)
-		q.Vers, data = guint32(data)
-		q.Path, data = guint64(data)
-		w.wqid = append(w.wqid, q)
+	var err error
+	b := data
+	var n uint16
+	if n, b, err = guint16(b); err != nil {
+		return decodeError(plan9.Rwalk, "nwqid", data, b, err)
+	}
+	for i := uint16(0); i < n; i++ {
+		var x plan9.QID
+		if x, b, err = gqid(b); err != nil {
+			return decodeError(plan9.Rwalk, "wqid", data, b, err)
+		}
+		w.wqid = append(w.wqid, x)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rwalk, "size", data, b, ErrTrailingData)
 	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (w *WalkResp) Size() plan9.Size {
+	n := 9
+	n += 13 * len(w.wqid)
+	return plan9.Size(n)
+}
+
+func (w *WalkResp) MarshalBinary() ([]byte, error) {
+	return w.appendBinary(make([]byte, 0, w.Size()))
+}
+
+func (w *WalkResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rwalk, w.header.tag)
+	b = pbit16(b, uint16(len(w.wqid)))
+	for _, x := range w.wqid {
+		b = pqid(b, x)
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // OpenReq is a 9P Topen message
 //
 // 	size[4] Topen tag[2] fid[4] mode[1]
@@ -200,12 +665,48 @@ type OpenReq struct {
 	mode   uint8
 }
 
+// Header returns the header of the message.
+func (o *OpenReq) Header() Header { return o.header }
+
+// Fid returns the fid field of the message.
+func (o *OpenReq) Fid() plan9.FID { return o.fid }
+
+// Mode returns the mode field of the message.
+func (o *OpenReq) Mode() uint8 { return o.mode }
+
 func (o *OpenReq) UnmarshalBinary(data []byte) error {
-	o.fid, data = gfid(data)
-	o.mode, data = guint8(data)
+	var err error
+	b := data
+	if o.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Topen, "fid", data, b, err)
+	}
+	if o.mode, b, err = guint8(b); err != nil {
+		return decodeError(plan9.Topen, "mode", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Topen, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (o *OpenReq) Size() plan9.Size {
+	return 12
+}
+
+func (o *OpenReq) MarshalBinary() ([]byte, error) {
+	return o.appendBinary(make([]byte, 0, o.Size()))
+}
+
+func (o *OpenReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Topen, o.header.tag)
+	b = pfid(b, o.fid)
+	b = pbit8(b, o.mode)
+	psize(b[n:])
+	return b, nil
+}
+
 // OpenResp is a 9P Ropen message
 //
 // 	size[4] Ropen tag[2] qid[13] iounit[4]
@@ -216,34 +717,138 @@ type OpenResp struct {
 	iounit uint32
 }
 
+// Header returns the header of the message.
+func (o *OpenResp) Header() Header { return o.header }
+
+// Qid returns the qid field of the message.
+func (o *OpenResp) Qid() plan9.QID { return o.qid }
+
+// Iounit returns the iounit field of the message.
+func (o *OpenResp) Iounit() uint32 { return o.iounit }
+
 func (o *OpenResp) UnmarshalBinary(data []byte) error {
-	o.qid.Type, data = guint8(data)
-	o.qid.Vers, data = guint32(data)
-	o.qid.Path, data = guint64(data)
-	o.iounit, data = guint32(data)
+	var err error
+	b := data
+	if o.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Ropen, "qid", data, b, err)
+	}
+	if o.iounit, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Ropen, "iounit", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Ropen, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (o *OpenResp) Size() plan9.Size {
+	return 24
+}
+
+func (o *OpenResp) MarshalBinary() ([]byte, error) {
+	return o.appendBinary(make([]byte, 0, o.Size()))
+}
+
+func (o *OpenResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Ropen, o.header.tag)
+	b = pqid(b, o.qid)
+	b = pbit32(b, o.iounit)
+	psize(b[n:])
+	return b, nil
+}
+
 // CreateReq is a 9P Tcreate message
 //
-// 	size[4] Tcreate tag[2] fid[4] name[s] perm[4] mode[1]
+// 	size[4] Tcreate tag[2] fid[4] name[s] perm[4] mode[1] .u extension[s]
 //
 type CreateReq struct {
-	header Header
-	fid    plan9.FID
-	name   string
-	perm   uint32
-	mode   uint8
+	header    Header
+	fid       plan9.FID
+	name      string
+	perm      uint32
+	mode      uint8
+	extension string
 }
 
+// Header returns the header of the message.
+func (c *CreateReq) Header() Header { return c.header }
+
+// Fid returns the fid field of the message.
+func (c *CreateReq) Fid() plan9.FID { return c.fid }
+
+// Name returns the name field of the message.
+func (c *CreateReq) Name() string { return c.name }
+
+// Perm returns the perm field of the message.
+func (c *CreateReq) Perm() uint32 { return c.perm }
+
+// Mode returns the mode field of the message.
+func (c *CreateReq) Mode() uint8 { return c.mode }
+
+// Extension returns the extension field of the message.
+func (c *CreateReq) Extension() string { return c.extension }
+
 func (c *CreateReq) UnmarshalBinary(data []byte) error {
-	c.fid, data = gfid(data)
-	c.name, data = gstring(data)
-	c.perm, data = guint32(data)
-	c.mode, data = guint8(data)
+	var err error
+	b := data
+	if c.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tcreate, "fid", data, b, err)
+	}
+	if c.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tcreate, "name", data, b, err)
+	}
+	if c.perm, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tcreate, "perm", data, b, err)
+	}
+	if c.mode, b, err = guint8(b); err != nil {
+		return decodeError(plan9.Tcreate, "mode", data, b, err)
+	}
+	if c.header.dotu() {
+		if c.extension, b, err = gstring(b); err != nil {
+			return decodeError(plan9.Tcreate, "extension", data, b, err)
+		}
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tcreate, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (c *CreateReq) Size() plan9.Size {
+	n := 18
+	n += len(c.name)
+	if c.header.dotu() {
+		n += 2 + len(c.extension)
+	}
+	return plan9.Size(n)
+}
+
+func (c *CreateReq) MarshalBinary() ([]byte, error) {
+	return c.appendBinary(make([]byte, 0, c.Size()))
+}
+
+func (c *CreateReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tcreate, c.header.tag)
+	b = pfid(b, c.fid)
+	if b, err = pstring(b, c.name); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, c.perm)
+	b = pbit8(b, c.mode)
+	if c.header.dotu() {
+		if b, err = pstring(b, c.extension); err != nil {
+			return nil, err
+		}
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // CreateResp is a 9P Rcreate message
 //
 // 	size[4] Rcreate tag[2] qid[13] iounit[4]
@@ -254,14 +859,48 @@ type CreateResp struct {
 	iounit uint32
 }
 
+// Header returns the header of the message.
+func (c *CreateResp) Header() Header { return c.header }
+
+// Qid returns the qid field of the message.
+func (c *CreateResp) Qid() plan9.QID { return c.qid }
+
+// Iounit returns the iounit field of the message.
+func (c *CreateResp) Iounit() uint32 { return c.iounit }
+
 func (c *CreateResp) UnmarshalBinary(data []byte) error {
-	c.qid.Type, data = guint8(data)
-	c.qid.Vers, data = guint32(data)
-	c.qid.Path, data = guint64(data)
-	c.iounit, data = guint32(data)
+	var err error
+	b := data
+	if c.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rcreate, "qid", data, b, err)
+	}
+	if c.iounit, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rcreate, "iounit", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rcreate, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (c *CreateResp) Size() plan9.Size {
+	return 24
+}
+
+func (c *CreateResp) MarshalBinary() ([]byte, error) {
+	return c.appendBinary(make([]byte, 0, c.Size()))
+}
+
+func (c *CreateResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rcreate, c.header.tag)
+	b = pqid(b, c.qid)
+	b = pbit32(b, c.iounit)
+	psize(b[n:])
+	return b, nil
+}
+
 // ReadReq is a 9P Tread message
 //
 // 	size[4] Tread tag[2] fid[4] offset[8] count[4]
@@ -273,13 +912,55 @@ type ReadReq struct {
 	count  uint32
 }
 
+// Header returns the header of the message.
+func (r *ReadReq) Header() Header { return r.header }
+
+// Fid returns the fid field of the message.
+func (r *ReadReq) Fid() plan9.FID { return r.fid }
+
+// Offset returns the offset field of the message.
+func (r *ReadReq) Offset() uint64 { return r.offset }
+
+// Count returns the count field of the message.
+func (r *ReadReq) Count() uint32 { return r.count }
+
 func (r *ReadReq) UnmarshalBinary(data []byte) error {
-	r.fid, data = gfid(data)
-	r.offset, data = guint64(data)
-	r.count, data = guint32(data)
+	var err error
+	b := data
+	if r.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tread, "fid", data, b, err)
+	}
+	if r.offset, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tread, "offset", data, b, err)
+	}
+	if r.count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tread, "count", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tread, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (r *ReadReq) Size() plan9.Size {
+	return 23
+}
+
+func (r *ReadReq) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *ReadReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tread, r.header.tag)
+	b = pfid(b, r.fid)
+	b = pbit64(b, r.offset)
+	b = pbit32(b, r.count)
+	psize(b[n:])
+	return b, nil
+}
+
 // ReadResp is a 9P Rread message
 //
 // 	size[4] Rread tag[2] count[4] data[count]
@@ -289,13 +970,48 @@ type ReadResp struct {
 	data   []byte
 }
 
+// Header returns the header of the message.
+func (r *ReadResp) Header() Header { return r.header }
+
+// Data returns the data field of the message.
+func (r *ReadResp) Data() []byte { return r.data }
+
 func (r *ReadResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
 	var count uint32
-	count, data = guint32(data)
-	r.data = data[:count]
+	if count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rread, "count", data, b, err)
+	}
+	if r.data, b, err = gdata(b, count); err != nil {
+		return decodeError(plan9.Rread, "data", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rread, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (r *ReadResp) Size() plan9.Size {
+	n := 11
+	n += len(r.data)
+	return plan9.Size(n)
+}
+
+func (r *ReadResp) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *ReadResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rread, r.header.tag)
+	b = pbit32(b, uint32(len(r.data)))
+	b = append(b, r.data...)
+	psize(b[n:])
+	return b, nil
+}
+
 // WriteReq is a 9P Twrite message
 //
 // 	size[4] Twrite tag[2] fid[4] offset[8] count[4] data[count]
@@ -307,15 +1023,62 @@ type WriteReq struct {
 	data   []byte
 }
 
+// Header returns the header of the message.
+func (w *WriteReq) Header() Header { return w.header }
+
+// Fid returns the fid field of the message.
+func (w *WriteReq) Fid() plan9.FID { return w.fid }
+
+// Offset returns the offset field of the message.
+func (w *WriteReq) Offset() uint64 { return w.offset }
+
+// Data returns the data field of the message.
+func (w *WriteReq) Data() []byte { return w.data }
+
 func (w *WriteReq) UnmarshalBinary(data []byte) error {
-	w.fid, data = gfid(data)
-	w.offset, data = guint64(data)
+	var err error
+	b := data
+	if w.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Twrite, "fid", data, b, err)
+	}
+	if w.offset, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Twrite, "offset", data, b, err)
+	}
 	var count uint32
-	count, data = guint32(data)
-	w.data = data[:count]
+	if count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Twrite, "count", data, b, err)
+	}
+	if w.data, b, err = gdata(b, count); err != nil {
+		return decodeError(plan9.Twrite, "data", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Twrite, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (w *WriteReq) Size() plan9.Size {
+	n := 23
+	n += len(w.data)
+	return plan9.Size(n)
+}
+
+func (w *WriteReq) MarshalBinary() ([]byte, error) {
+	return w.appendBinary(make([]byte, 0, w.Size()))
+}
+
+func (w *WriteReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Twrite, w.header.tag)
+	b = pfid(b, w.fid)
+	b = pbit64(b, w.offset)
+	b = pbit32(b, uint32(len(w.data)))
+	b = append(b, w.data...)
+	psize(b[n:])
+	return b, nil
+}
+
 // WriteResp is a 9P Rwrite message
 //
 // 	size[4] Rwrite tag[2] count[4]
@@ -325,25 +1088,85 @@ type WriteResp struct {
 	count  uint32
 }
 
+// Header returns the header of the message.
+func (w *WriteResp) Header() Header { return w.header }
+
+// Count returns the count field of the message.
+func (w *WriteResp) Count() uint32 { return w.count }
+
 func (w *WriteResp) UnmarshalBinary(data []byte) error {
-	w.count, data = guint32(data)
+	var err error
+	b := data
+	if w.count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rwrite, "count", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rwrite, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
-// ClunkReq is a 9P Tclunk message
-//
-// 	size[4] Tclunk tag[2] fid[4]
+// Size returns the size of the message on the wire.
+func (w *WriteResp) Size() plan9.Size {
+	return 11
+}
+
+func (w *WriteResp) MarshalBinary() ([]byte, error) {
+	return w.appendBinary(make([]byte, 0, w.Size()))
+}
+
+func (w *WriteResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rwrite, w.header.tag)
+	b = pbit32(b, w.count)
+	psize(b[n:])
+	return b, nil
+}
+
+// ClunkReq is a 9P Tclunk message
+//
+// 	size[4] Tclunk tag[2] fid[4]
 //
 type ClunkReq struct {
 	header Header
 	fid    plan9.FID
 }
 
+// Header returns the header of the message.
+func (c *ClunkReq) Header() Header { return c.header }
+
+// Fid returns the fid field of the message.
+func (c *ClunkReq) Fid() plan9.FID { return c.fid }
+
 func (c *ClunkReq) UnmarshalBinary(data []byte) error {
-	c.fid, data = gfid(data)
+	var err error
+	b := data
+	if c.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tclunk, "fid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tclunk, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (c *ClunkReq) Size() plan9.Size {
+	return 11
+}
+
+func (c *ClunkReq) MarshalBinary() ([]byte, error) {
+	return c.appendBinary(make([]byte, 0, c.Size()))
+}
+
+func (c *ClunkReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tclunk, c.header.tag)
+	b = pfid(b, c.fid)
+	psize(b[n:])
+	return b, nil
+}
+
 // ClunkResp is a 9P Rclunk message
 //
 // 	size[4] Rclunk tag[2]
@@ -352,10 +1175,32 @@ type ClunkResp struct {
 	header Header
 }
 
+// Header returns the header of the message.
+func (c *ClunkResp) Header() Header { return c.header }
+
 func (c *ClunkResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rclunk, "size", data, data, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (c *ClunkResp) Size() plan9.Size {
+	return 7
+}
+
+func (c *ClunkResp) MarshalBinary() ([]byte, error) {
+	return c.appendBinary(make([]byte, 0, c.Size()))
+}
+
+func (c *ClunkResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rclunk, c.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
 // RemoveReq is a 9P Tremove message
 //
 // 	size[4] Tremove tag[2] fid[4]
@@ -365,11 +1210,41 @@ type RemoveReq struct {
 	fid    plan9.FID
 }
 
+// Header returns the header of the message.
+func (r *RemoveReq) Header() Header { return r.header }
+
+// Fid returns the fid field of the message.
+func (r *RemoveReq) Fid() plan9.FID { return r.fid }
+
 func (r *RemoveReq) UnmarshalBinary(data []byte) error {
-	r.fid, data = gfid(data)
+	var err error
+	b := data
+	if r.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tremove, "fid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tremove, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (r *RemoveReq) Size() plan9.Size {
+	return 11
+}
+
+func (r *RemoveReq) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *RemoveReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tremove, r.header.tag)
+	b = pfid(b, r.fid)
+	psize(b[n:])
+	return b, nil
+}
+
 // RemoveResp is a 9P Rremove message
 //
 // 	size[4] Rremove tag[2]
@@ -378,10 +1253,32 @@ type RemoveResp struct {
 	header Header
 }
 
+// Header returns the header of the message.
+func (r *RemoveResp) Header() Header { return r.header }
+
 func (r *RemoveResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rremove, "size", data, data, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (r *RemoveResp) Size() plan9.Size {
+	return 7
+}
+
+func (r *RemoveResp) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *RemoveResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rremove, r.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
 // StatReq is a 9P Tstat message
 //
 // 	size[4] Tstat tag[2] fid[4]
@@ -391,11 +1288,41 @@ type StatReq struct {
 	fid    plan9.FID
 }
 
+// Header returns the header of the message.
+func (s *StatReq) Header() Header { return s.header }
+
+// Fid returns the fid field of the message.
+func (s *StatReq) Fid() plan9.FID { return s.fid }
+
 func (s *StatReq) UnmarshalBinary(data []byte) error {
-	s.fid, data = gfid(data)
+	var err error
+	b := data
+	if s.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tstat, "fid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tstat, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (s *StatReq) Size() plan9.Size {
+	return 11
+}
+
+func (s *StatReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *StatReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tstat, s.header.tag)
+	b = pfid(b, s.fid)
+	psize(b[n:])
+	return b, nil
+}
+
 // StatResp is a 9P Rstat message
 //
 // 	size[4] Rstat tag[2] stat[n]
@@ -405,12 +1332,46 @@ type StatResp struct {
 	stat   *plan9.Dir
 }
 
+// Header returns the header of the message.
+func (s *StatResp) Header() Header { return s.header }
+
+// Stat returns the stat field of the message.
+func (s *StatResp) Stat() *plan9.Dir { return s.stat }
+
 func (s *StatResp) UnmarshalBinary(data []byte) error {
-	_, data = guint16(data) // BUG(sevki): see https://9p.io/magic/man2html/5/stat
-	s.stat, data = unmarshaldir(data)
+	var err error
+	b := data
+	if s.stat, b, err = unmarshalstat(b, s.header.dotu()); err != nil {
+		return decodeError(plan9.Rstat, "stat", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rstat, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (s *StatResp) Size() plan9.Size {
+	n := 7
+	n += statsize(s.stat, s.header.dotu())
+	return plan9.Size(n)
+}
+
+func (s *StatResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *StatResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Rstat, s.header.tag)
+	if b, err = marshalstat(b, s.stat, s.header.dotu()); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // WstatReq is a 9P Twstat message
 //
 // 	size[4] Twstat tag[2] fid[4] stat[n]
@@ -421,13 +1382,53 @@ type WstatReq struct {
 	stat   *plan9.Dir
 }
 
+// Header returns the header of the message.
+func (w *WstatReq) Header() Header { return w.header }
+
+// Fid returns the fid field of the message.
+func (w *WstatReq) Fid() plan9.FID { return w.fid }
+
+// Stat returns the stat field of the message.
+func (w *WstatReq) Stat() *plan9.Dir { return w.stat }
+
 func (w *WstatReq) UnmarshalBinary(data []byte) error {
-	w.fid, data = gfid(data)
-	_, data = guint16(data) // BUG(sevki): see https://9p.io/magic/man2html/5/stat
-	w.stat, data = unmarshaldir(data)
+	var err error
+	b := data
+	if w.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Twstat, "fid", data, b, err)
+	}
+	if w.stat, b, err = unmarshalstat(b, w.header.dotu()); err != nil {
+		return decodeError(plan9.Twstat, "stat", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Twstat, "size", data, b, ErrTrailingData)
+	}
 	return nil
 }
 
+// Size returns the size of the message on the wire.
+func (w *WstatReq) Size() plan9.Size {
+	n := 11
+	n += statsize(w.stat, w.header.dotu())
+	return plan9.Size(n)
+}
+
+func (w *WstatReq) MarshalBinary() ([]byte, error) {
+	return w.appendBinary(make([]byte, 0, w.Size()))
+}
+
+func (w *WstatReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Twstat, w.header.tag)
+	b = pfid(b, w.fid)
+	if b, err = marshalstat(b, w.stat, w.header.dotu()); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
 // WstatResp is a 9P Rwstat message
 //
 // 	size[4] Rwstat tag[2]
@@ -436,67 +1437,3723 @@ type WstatResp struct {
 	header Header
 }
 
+// Header returns the header of the message.
+func (w *WstatResp) Header() Header { return w.header }
+
 func (w *WstatResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rwstat, "size", data, data, ErrTrailingData)
+	}
 	return nil
 }
 
-func newMessage(h Header) Message {
-	var msg Message
-	switch h.mtype {
-	case tversion:
-		return &VersionReq{header: h}
-	case rversion:
-		return &VersionResp{header: h}
-	case tauth:
-		return &AuthReq{header: h}
-	case rauth:
-		return &AuthResp{header: h}
-	case rerror:
-		return &ErrorResp{header: h}
-	case tflush:
-		return &FlushReq{header: h}
-	case rflush:
-		return &FlushResp{header: h}
-	case tattach:
-		return &AttachReq{header: h}
-	case rattach:
-		return &AttachResp{header: h}
-	case twalk:
-		return &WalkReq{header: h}
-	case rwalk:
-		return &WalkResp{header: h}
-	case topen:
-		return &OpenReq{header: h}
-	case ropen:
-		return &OpenResp{header: h}
-	case tcreate:
-		return &CreateReq{header: h}
-	case rcreate:
-		return &CreateResp{header: h}
-	case tread:
-		return &ReadReq{header: h}
-	case rread:
-		return &ReadResp{header: h}
-	case twrite:
-		return &WriteReq{header: h}
-	case rwrite:
-		return &WriteResp{header: h}
-	case tclunk:
-		return &ClunkReq{header: h}
-	case rclunk:
-		return &ClunkResp{header: h}
-	case tremove:
-		return &RemoveReq{header: h}
-	case rremove:
-		return &RemoveResp{header: h}
-	case tstat:
-		return &StatReq{header: h}
-	case rstat:
-		return &StatResp{header: h}
-	case twstat:
-		return &WstatReq{header: h}
-	case rwstat:
-		return &WstatResp{header: h}
+// Size returns the size of the message on the wire.
+func (w *WstatResp) Size() plan9.Size {
+	return 7
+}
+
+func (w *WstatResp) MarshalBinary() ([]byte, error) {
+	return w.appendBinary(make([]byte, 0, w.Size()))
+}
+
+func (w *WstatResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rwstat, w.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// LerrorResp is a 9P Rlerror message
+//
+// 	size[4] Rlerror tag[2] ecode[4]
+//
+type LerrorResp struct {
+	header Header
+	ecode  uint32
+}
+
+// Header returns the header of the message.
+func (l *LerrorResp) Header() Header { return l.header }
+
+// Ecode returns the ecode field of the message.
+func (l *LerrorResp) Ecode() uint32 { return l.ecode }
+
+func (l *LerrorResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.ecode, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rlerror, "ecode", data, b, err)
 	}
-	return msg
+	if len(b) != 0 {
+		return decodeError(plan9.Rlerror, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LerrorResp) Size() plan9.Size {
+	return 11
+}
+
+func (l *LerrorResp) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LerrorResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rlerror, l.header.tag)
+	b = pbit32(b, l.ecode)
+	psize(b[n:])
+	return b, nil
+}
+
+// StatfsReq is a 9P Tstatfs message
+//
+// 	size[4] Tstatfs tag[2] fid[4]
+//
+type StatfsReq struct {
+	header Header
+	fid    plan9.FID
+}
+
+// Header returns the header of the message.
+func (s *StatfsReq) Header() Header { return s.header }
+
+// Fid returns the fid field of the message.
+func (s *StatfsReq) Fid() plan9.FID { return s.fid }
+
+func (s *StatfsReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tstatfs, "fid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tstatfs, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *StatfsReq) Size() plan9.Size {
+	return 11
+}
+
+func (s *StatfsReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *StatfsReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tstatfs, s.header.tag)
+	b = pfid(b, s.fid)
+	psize(b[n:])
+	return b, nil
+}
+
+// StatfsResp is a 9P Rstatfs message
+//
+// 	size[4] Rstatfs tag[2] fstype[4] bsize[4] blocks[8] bfree[8] bavail[8] files[8] ffree[8] fsid[8] namelen[4]
+//
+type StatfsResp struct {
+	header  Header
+	fstype  uint32
+	bsize   uint32
+	blocks  uint64
+	bfree   uint64
+	bavail  uint64
+	files   uint64
+	ffree   uint64
+	fsid    uint64
+	namelen uint32
+}
+
+// Header returns the header of the message.
+func (s *StatfsResp) Header() Header { return s.header }
+
+// Fstype returns the fstype field of the message.
+func (s *StatfsResp) Fstype() uint32 { return s.fstype }
+
+// Bsize returns the bsize field of the message.
+func (s *StatfsResp) Bsize() uint32 { return s.bsize }
+
+// Blocks returns the blocks field of the message.
+func (s *StatfsResp) Blocks() uint64 { return s.blocks }
+
+// Bfree returns the bfree field of the message.
+func (s *StatfsResp) Bfree() uint64 { return s.bfree }
+
+// Bavail returns the bavail field of the message.
+func (s *StatfsResp) Bavail() uint64 { return s.bavail }
+
+// Files returns the files field of the message.
+func (s *StatfsResp) Files() uint64 { return s.files }
+
+// Ffree returns the ffree field of the message.
+func (s *StatfsResp) Ffree() uint64 { return s.ffree }
+
+// Fsid returns the fsid field of the message.
+func (s *StatfsResp) Fsid() uint64 { return s.fsid }
+
+// Namelen returns the namelen field of the message.
+func (s *StatfsResp) Namelen() uint32 { return s.namelen }
+
+func (s *StatfsResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.fstype, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rstatfs, "fstype", data, b, err)
+	}
+	if s.bsize, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rstatfs, "bsize", data, b, err)
+	}
+	if s.blocks, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rstatfs, "blocks", data, b, err)
+	}
+	if s.bfree, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rstatfs, "bfree", data, b, err)
+	}
+	if s.bavail, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rstatfs, "bavail", data, b, err)
+	}
+	if s.files, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rstatfs, "files", data, b, err)
+	}
+	if s.ffree, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rstatfs, "ffree", data, b, err)
+	}
+	if s.fsid, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rstatfs, "fsid", data, b, err)
+	}
+	if s.namelen, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rstatfs, "namelen", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rstatfs, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *StatfsResp) Size() plan9.Size {
+	return 67
+}
+
+func (s *StatfsResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *StatfsResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rstatfs, s.header.tag)
+	b = pbit32(b, s.fstype)
+	b = pbit32(b, s.bsize)
+	b = pbit64(b, s.blocks)
+	b = pbit64(b, s.bfree)
+	b = pbit64(b, s.bavail)
+	b = pbit64(b, s.files)
+	b = pbit64(b, s.ffree)
+	b = pbit64(b, s.fsid)
+	b = pbit32(b, s.namelen)
+	psize(b[n:])
+	return b, nil
+}
+
+// LopenReq is a 9P Tlopen message
+//
+// 	size[4] Tlopen tag[2] fid[4] flags[4]
+//
+type LopenReq struct {
+	header Header
+	fid    plan9.FID
+	flags  uint32
+}
+
+// Header returns the header of the message.
+func (l *LopenReq) Header() Header { return l.header }
+
+// Fid returns the fid field of the message.
+func (l *LopenReq) Fid() plan9.FID { return l.fid }
+
+// Flags returns the flags field of the message.
+func (l *LopenReq) Flags() uint32 { return l.flags }
+
+func (l *LopenReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tlopen, "fid", data, b, err)
+	}
+	if l.flags, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tlopen, "flags", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tlopen, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LopenReq) Size() plan9.Size {
+	return 15
+}
+
+func (l *LopenReq) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LopenReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tlopen, l.header.tag)
+	b = pfid(b, l.fid)
+	b = pbit32(b, l.flags)
+	psize(b[n:])
+	return b, nil
+}
+
+// LopenResp is a 9P Rlopen message
+//
+// 	size[4] Rlopen tag[2] qid[13] iounit[4]
+//
+type LopenResp struct {
+	header Header
+	qid    plan9.QID
+	iounit uint32
+}
+
+// Header returns the header of the message.
+func (l *LopenResp) Header() Header { return l.header }
+
+// Qid returns the qid field of the message.
+func (l *LopenResp) Qid() plan9.QID { return l.qid }
+
+// Iounit returns the iounit field of the message.
+func (l *LopenResp) Iounit() uint32 { return l.iounit }
+
+func (l *LopenResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rlopen, "qid", data, b, err)
+	}
+	if l.iounit, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rlopen, "iounit", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rlopen, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LopenResp) Size() plan9.Size {
+	return 24
+}
+
+func (l *LopenResp) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LopenResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rlopen, l.header.tag)
+	b = pqid(b, l.qid)
+	b = pbit32(b, l.iounit)
+	psize(b[n:])
+	return b, nil
+}
+
+// LcreateReq is a 9P Tlcreate message
+//
+// 	size[4] Tlcreate tag[2] fid[4] name[s] flags[4] mode[4] gid[4]
+//
+type LcreateReq struct {
+	header Header
+	fid    plan9.FID
+	name   string
+	flags  uint32
+	mode   uint32
+	gid    uint32
+}
+
+// Header returns the header of the message.
+func (l *LcreateReq) Header() Header { return l.header }
+
+// Fid returns the fid field of the message.
+func (l *LcreateReq) Fid() plan9.FID { return l.fid }
+
+// Name returns the name field of the message.
+func (l *LcreateReq) Name() string { return l.name }
+
+// Flags returns the flags field of the message.
+func (l *LcreateReq) Flags() uint32 { return l.flags }
+
+// Mode returns the mode field of the message.
+func (l *LcreateReq) Mode() uint32 { return l.mode }
+
+// Gid returns the gid field of the message.
+func (l *LcreateReq) Gid() uint32 { return l.gid }
+
+func (l *LcreateReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tlcreate, "fid", data, b, err)
+	}
+	if l.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tlcreate, "name", data, b, err)
+	}
+	if l.flags, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tlcreate, "flags", data, b, err)
+	}
+	if l.mode, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tlcreate, "mode", data, b, err)
+	}
+	if l.gid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tlcreate, "gid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tlcreate, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LcreateReq) Size() plan9.Size {
+	n := 25
+	n += len(l.name)
+	return plan9.Size(n)
+}
+
+func (l *LcreateReq) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LcreateReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tlcreate, l.header.tag)
+	b = pfid(b, l.fid)
+	if b, err = pstring(b, l.name); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, l.flags)
+	b = pbit32(b, l.mode)
+	b = pbit32(b, l.gid)
+	psize(b[n:])
+	return b, nil
+}
+
+// LcreateResp is a 9P Rlcreate message
+//
+// 	size[4] Rlcreate tag[2] qid[13] iounit[4]
+//
+type LcreateResp struct {
+	header Header
+	qid    plan9.QID
+	iounit uint32
+}
+
+// Header returns the header of the message.
+func (l *LcreateResp) Header() Header { return l.header }
+
+// Qid returns the qid field of the message.
+func (l *LcreateResp) Qid() plan9.QID { return l.qid }
+
+// Iounit returns the iounit field of the message.
+func (l *LcreateResp) Iounit() uint32 { return l.iounit }
+
+func (l *LcreateResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rlcreate, "qid", data, b, err)
+	}
+	if l.iounit, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rlcreate, "iounit", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rlcreate, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LcreateResp) Size() plan9.Size {
+	return 24
+}
+
+func (l *LcreateResp) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LcreateResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rlcreate, l.header.tag)
+	b = pqid(b, l.qid)
+	b = pbit32(b, l.iounit)
+	psize(b[n:])
+	return b, nil
+}
+
+// SymlinkReq is a 9P Tsymlink message
+//
+// 	size[4] Tsymlink tag[2] fid[4] name[s] symtgt[s] gid[4]
+//
+type SymlinkReq struct {
+	header Header
+	fid    plan9.FID
+	name   string
+	symtgt string
+	gid    uint32
+}
+
+// Header returns the header of the message.
+func (s *SymlinkReq) Header() Header { return s.header }
+
+// Fid returns the fid field of the message.
+func (s *SymlinkReq) Fid() plan9.FID { return s.fid }
+
+// Name returns the name field of the message.
+func (s *SymlinkReq) Name() string { return s.name }
+
+// Symtgt returns the symtgt field of the message.
+func (s *SymlinkReq) Symtgt() string { return s.symtgt }
+
+// Gid returns the gid field of the message.
+func (s *SymlinkReq) Gid() uint32 { return s.gid }
+
+func (s *SymlinkReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tsymlink, "fid", data, b, err)
+	}
+	if s.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tsymlink, "name", data, b, err)
+	}
+	if s.symtgt, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tsymlink, "symtgt", data, b, err)
+	}
+	if s.gid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tsymlink, "gid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tsymlink, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SymlinkReq) Size() plan9.Size {
+	n := 19
+	n += len(s.name)
+	n += len(s.symtgt)
+	return plan9.Size(n)
+}
+
+func (s *SymlinkReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SymlinkReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tsymlink, s.header.tag)
+	b = pfid(b, s.fid)
+	if b, err = pstring(b, s.name); err != nil {
+		return nil, err
+	}
+	if b, err = pstring(b, s.symtgt); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, s.gid)
+	psize(b[n:])
+	return b, nil
+}
+
+// SymlinkResp is a 9P Rsymlink message
+//
+// 	size[4] Rsymlink tag[2] qid[13]
+//
+type SymlinkResp struct {
+	header Header
+	qid    plan9.QID
+}
+
+// Header returns the header of the message.
+func (s *SymlinkResp) Header() Header { return s.header }
+
+// Qid returns the qid field of the message.
+func (s *SymlinkResp) Qid() plan9.QID { return s.qid }
+
+func (s *SymlinkResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rsymlink, "qid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rsymlink, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SymlinkResp) Size() plan9.Size {
+	return 20
+}
+
+func (s *SymlinkResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SymlinkResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rsymlink, s.header.tag)
+	b = pqid(b, s.qid)
+	psize(b[n:])
+	return b, nil
+}
+
+// MknodReq is a 9P Tmknod message
+//
+// 	size[4] Tmknod tag[2] dfid[4] name[s] mode[4] major[4] minor[4] gid[4]
+//
+type MknodReq struct {
+	header Header
+	dfid   plan9.FID
+	name   string
+	mode   uint32
+	major  uint32
+	minor  uint32
+	gid    uint32
+}
+
+// Header returns the header of the message.
+func (m *MknodReq) Header() Header { return m.header }
+
+// Dfid returns the dfid field of the message.
+func (m *MknodReq) Dfid() plan9.FID { return m.dfid }
+
+// Name returns the name field of the message.
+func (m *MknodReq) Name() string { return m.name }
+
+// Mode returns the mode field of the message.
+func (m *MknodReq) Mode() uint32 { return m.mode }
+
+// Major returns the major field of the message.
+func (m *MknodReq) Major() uint32 { return m.major }
+
+// Minor returns the minor field of the message.
+func (m *MknodReq) Minor() uint32 { return m.minor }
+
+// Gid returns the gid field of the message.
+func (m *MknodReq) Gid() uint32 { return m.gid }
+
+func (m *MknodReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if m.dfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tmknod, "dfid", data, b, err)
+	}
+	if m.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tmknod, "name", data, b, err)
+	}
+	if m.mode, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tmknod, "mode", data, b, err)
+	}
+	if m.major, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tmknod, "major", data, b, err)
+	}
+	if m.minor, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tmknod, "minor", data, b, err)
+	}
+	if m.gid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tmknod, "gid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tmknod, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (m *MknodReq) Size() plan9.Size {
+	n := 29
+	n += len(m.name)
+	return plan9.Size(n)
+}
+
+func (m *MknodReq) MarshalBinary() ([]byte, error) {
+	return m.appendBinary(make([]byte, 0, m.Size()))
+}
+
+func (m *MknodReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tmknod, m.header.tag)
+	b = pfid(b, m.dfid)
+	if b, err = pstring(b, m.name); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, m.mode)
+	b = pbit32(b, m.major)
+	b = pbit32(b, m.minor)
+	b = pbit32(b, m.gid)
+	psize(b[n:])
+	return b, nil
+}
+
+// MknodResp is a 9P Rmknod message
+//
+// 	size[4] Rmknod tag[2] qid[13]
+//
+type MknodResp struct {
+	header Header
+	qid    plan9.QID
+}
+
+// Header returns the header of the message.
+func (m *MknodResp) Header() Header { return m.header }
+
+// Qid returns the qid field of the message.
+func (m *MknodResp) Qid() plan9.QID { return m.qid }
+
+func (m *MknodResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if m.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rmknod, "qid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rmknod, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (m *MknodResp) Size() plan9.Size {
+	return 20
+}
+
+func (m *MknodResp) MarshalBinary() ([]byte, error) {
+	return m.appendBinary(make([]byte, 0, m.Size()))
+}
+
+func (m *MknodResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rmknod, m.header.tag)
+	b = pqid(b, m.qid)
+	psize(b[n:])
+	return b, nil
+}
+
+// RenameReq is a 9P Trename message
+//
+// 	size[4] Trename tag[2] fid[4] dfid[4] name[s]
+//
+type RenameReq struct {
+	header Header
+	fid    plan9.FID
+	dfid   plan9.FID
+	name   string
+}
+
+// Header returns the header of the message.
+func (r *RenameReq) Header() Header { return r.header }
+
+// Fid returns the fid field of the message.
+func (r *RenameReq) Fid() plan9.FID { return r.fid }
+
+// Dfid returns the dfid field of the message.
+func (r *RenameReq) Dfid() plan9.FID { return r.dfid }
+
+// Name returns the name field of the message.
+func (r *RenameReq) Name() string { return r.name }
+
+func (r *RenameReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if r.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Trename, "fid", data, b, err)
+	}
+	if r.dfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Trename, "dfid", data, b, err)
+	}
+	if r.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Trename, "name", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Trename, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *RenameReq) Size() plan9.Size {
+	n := 17
+	n += len(r.name)
+	return plan9.Size(n)
+}
+
+func (r *RenameReq) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *RenameReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Trename, r.header.tag)
+	b = pfid(b, r.fid)
+	b = pfid(b, r.dfid)
+	if b, err = pstring(b, r.name); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// RenameResp is a 9P Rrename message
+//
+// 	size[4] Rrename tag[2]
+//
+type RenameResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (r *RenameResp) Header() Header { return r.header }
+
+func (r *RenameResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rrename, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *RenameResp) Size() plan9.Size {
+	return 7
+}
+
+func (r *RenameResp) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *RenameResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rrename, r.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// ReadlinkReq is a 9P Treadlink message
+//
+// 	size[4] Treadlink tag[2] fid[4]
+//
+type ReadlinkReq struct {
+	header Header
+	fid    plan9.FID
+}
+
+// Header returns the header of the message.
+func (r *ReadlinkReq) Header() Header { return r.header }
+
+// Fid returns the fid field of the message.
+func (r *ReadlinkReq) Fid() plan9.FID { return r.fid }
+
+func (r *ReadlinkReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if r.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Treadlink, "fid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Treadlink, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *ReadlinkReq) Size() plan9.Size {
+	return 11
+}
+
+func (r *ReadlinkReq) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *ReadlinkReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Treadlink, r.header.tag)
+	b = pfid(b, r.fid)
+	psize(b[n:])
+	return b, nil
+}
+
+// ReadlinkResp is a 9P Rreadlink message
+//
+// 	size[4] Rreadlink tag[2] target[s]
+//
+type ReadlinkResp struct {
+	header Header
+	target string
+}
+
+// Header returns the header of the message.
+func (r *ReadlinkResp) Header() Header { return r.header }
+
+// Target returns the target field of the message.
+func (r *ReadlinkResp) Target() string { return r.target }
+
+func (r *ReadlinkResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if r.target, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Rreadlink, "target", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rreadlink, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *ReadlinkResp) Size() plan9.Size {
+	n := 9
+	n += len(r.target)
+	return plan9.Size(n)
+}
+
+func (r *ReadlinkResp) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *ReadlinkResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Rreadlink, r.header.tag)
+	if b, err = pstring(b, r.target); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// GetattrReq is a 9P Tgetattr message
+//
+// 	size[4] Tgetattr tag[2] fid[4] request_mask[8]
+//
+type GetattrReq struct {
+	header      Header
+	fid         plan9.FID
+	requestMask uint64
+}
+
+// Header returns the header of the message.
+func (g *GetattrReq) Header() Header { return g.header }
+
+// Fid returns the fid field of the message.
+func (g *GetattrReq) Fid() plan9.FID { return g.fid }
+
+// RequestMask returns the requestMask field of the message.
+func (g *GetattrReq) RequestMask() uint64 { return g.requestMask }
+
+func (g *GetattrReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if g.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tgetattr, "fid", data, b, err)
+	}
+	if g.requestMask, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tgetattr, "requestMask", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tgetattr, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (g *GetattrReq) Size() plan9.Size {
+	return 19
+}
+
+func (g *GetattrReq) MarshalBinary() ([]byte, error) {
+	return g.appendBinary(make([]byte, 0, g.Size()))
+}
+
+func (g *GetattrReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tgetattr, g.header.tag)
+	b = pfid(b, g.fid)
+	b = pbit64(b, g.requestMask)
+	psize(b[n:])
+	return b, nil
+}
+
+// GetattrResp is a 9P Rgetattr message
+//
+// 	size[4] Rgetattr tag[2] valid[8] qid[13] mode[4] uid[4] gid[4] nlink[8] rdev[8] length[8] blksize[8] blocks[8] atime_sec[8] atime_nsec[8] mtime_sec[8] mtime_nsec[8] ctime_sec[8] ctime_nsec[8] btime_sec[8] btime_nsec[8] gen[8] data_version[8]
+//
+type GetattrResp struct {
+	header      Header
+	valid       uint64
+	qid         plan9.QID
+	mode        uint32
+	uid         uint32
+	gid         uint32
+	nlink       uint64
+	rdev        uint64
+	length      uint64
+	blksize     uint64
+	blocks      uint64
+	atimeSec    uint64
+	atimeNsec   uint64
+	mtimeSec    uint64
+	mtimeNsec   uint64
+	ctimeSec    uint64
+	ctimeNsec   uint64
+	btimeSec    uint64
+	btimeNsec   uint64
+	gen         uint64
+	dataVersion uint64
+}
+
+// Header returns the header of the message.
+func (g *GetattrResp) Header() Header { return g.header }
+
+// Valid returns the valid field of the message.
+func (g *GetattrResp) Valid() uint64 { return g.valid }
+
+// Qid returns the qid field of the message.
+func (g *GetattrResp) Qid() plan9.QID { return g.qid }
+
+// Mode returns the mode field of the message.
+func (g *GetattrResp) Mode() uint32 { return g.mode }
+
+// Uid returns the uid field of the message.
+func (g *GetattrResp) Uid() uint32 { return g.uid }
+
+// Gid returns the gid field of the message.
+func (g *GetattrResp) Gid() uint32 { return g.gid }
+
+// Nlink returns the nlink field of the message.
+func (g *GetattrResp) Nlink() uint64 { return g.nlink }
+
+// Rdev returns the rdev field of the message.
+func (g *GetattrResp) Rdev() uint64 { return g.rdev }
+
+// Length returns the length field of the message.
+func (g *GetattrResp) Length() uint64 { return g.length }
+
+// Blksize returns the blksize field of the message.
+func (g *GetattrResp) Blksize() uint64 { return g.blksize }
+
+// Blocks returns the blocks field of the message.
+func (g *GetattrResp) Blocks() uint64 { return g.blocks }
+
+// AtimeSec returns the atimeSec field of the message.
+func (g *GetattrResp) AtimeSec() uint64 { return g.atimeSec }
+
+// AtimeNsec returns the atimeNsec field of the message.
+func (g *GetattrResp) AtimeNsec() uint64 { return g.atimeNsec }
+
+// MtimeSec returns the mtimeSec field of the message.
+func (g *GetattrResp) MtimeSec() uint64 { return g.mtimeSec }
+
+// MtimeNsec returns the mtimeNsec field of the message.
+func (g *GetattrResp) MtimeNsec() uint64 { return g.mtimeNsec }
+
+// CtimeSec returns the ctimeSec field of the message.
+func (g *GetattrResp) CtimeSec() uint64 { return g.ctimeSec }
+
+// CtimeNsec returns the ctimeNsec field of the message.
+func (g *GetattrResp) CtimeNsec() uint64 { return g.ctimeNsec }
+
+// BtimeSec returns the btimeSec field of the message.
+func (g *GetattrResp) BtimeSec() uint64 { return g.btimeSec }
+
+// BtimeNsec returns the btimeNsec field of the message.
+func (g *GetattrResp) BtimeNsec() uint64 { return g.btimeNsec }
+
+// Gen returns the gen field of the message.
+func (g *GetattrResp) Gen() uint64 { return g.gen }
+
+// DataVersion returns the dataVersion field of the message.
+func (g *GetattrResp) DataVersion() uint64 { return g.dataVersion }
+
+func (g *GetattrResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if g.valid, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "valid", data, b, err)
+	}
+	if g.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rgetattr, "qid", data, b, err)
+	}
+	if g.mode, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rgetattr, "mode", data, b, err)
+	}
+	if g.uid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rgetattr, "uid", data, b, err)
+	}
+	if g.gid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rgetattr, "gid", data, b, err)
+	}
+	if g.nlink, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "nlink", data, b, err)
+	}
+	if g.rdev, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "rdev", data, b, err)
+	}
+	if g.length, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "length", data, b, err)
+	}
+	if g.blksize, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "blksize", data, b, err)
+	}
+	if g.blocks, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "blocks", data, b, err)
+	}
+	if g.atimeSec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "atimeSec", data, b, err)
+	}
+	if g.atimeNsec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "atimeNsec", data, b, err)
+	}
+	if g.mtimeSec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "mtimeSec", data, b, err)
+	}
+	if g.mtimeNsec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "mtimeNsec", data, b, err)
+	}
+	if g.ctimeSec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "ctimeSec", data, b, err)
+	}
+	if g.ctimeNsec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "ctimeNsec", data, b, err)
+	}
+	if g.btimeSec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "btimeSec", data, b, err)
+	}
+	if g.btimeNsec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "btimeNsec", data, b, err)
+	}
+	if g.gen, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "gen", data, b, err)
+	}
+	if g.dataVersion, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetattr, "dataVersion", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rgetattr, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (g *GetattrResp) Size() plan9.Size {
+	return 160
+}
+
+func (g *GetattrResp) MarshalBinary() ([]byte, error) {
+	return g.appendBinary(make([]byte, 0, g.Size()))
+}
+
+func (g *GetattrResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rgetattr, g.header.tag)
+	b = pbit64(b, g.valid)
+	b = pqid(b, g.qid)
+	b = pbit32(b, g.mode)
+	b = pbit32(b, g.uid)
+	b = pbit32(b, g.gid)
+	b = pbit64(b, g.nlink)
+	b = pbit64(b, g.rdev)
+	b = pbit64(b, g.length)
+	b = pbit64(b, g.blksize)
+	b = pbit64(b, g.blocks)
+	b = pbit64(b, g.atimeSec)
+	b = pbit64(b, g.atimeNsec)
+	b = pbit64(b, g.mtimeSec)
+	b = pbit64(b, g.mtimeNsec)
+	b = pbit64(b, g.ctimeSec)
+	b = pbit64(b, g.ctimeNsec)
+	b = pbit64(b, g.btimeSec)
+	b = pbit64(b, g.btimeNsec)
+	b = pbit64(b, g.gen)
+	b = pbit64(b, g.dataVersion)
+	psize(b[n:])
+	return b, nil
+}
+
+// SetattrReq is a 9P Tsetattr message
+//
+// 	size[4] Tsetattr tag[2] fid[4] valid[4] mode[4] uid[4] gid[4] length[8] atime_sec[8] atime_nsec[8] mtime_sec[8] mtime_nsec[8]
+//
+type SetattrReq struct {
+	header    Header
+	fid       plan9.FID
+	valid     uint32
+	mode      uint32
+	uid       uint32
+	gid       uint32
+	length    uint64
+	atimeSec  uint64
+	atimeNsec uint64
+	mtimeSec  uint64
+	mtimeNsec uint64
+}
+
+// Header returns the header of the message.
+func (s *SetattrReq) Header() Header { return s.header }
+
+// Fid returns the fid field of the message.
+func (s *SetattrReq) Fid() plan9.FID { return s.fid }
+
+// Valid returns the valid field of the message.
+func (s *SetattrReq) Valid() uint32 { return s.valid }
+
+// Mode returns the mode field of the message.
+func (s *SetattrReq) Mode() uint32 { return s.mode }
+
+// Uid returns the uid field of the message.
+func (s *SetattrReq) Uid() uint32 { return s.uid }
+
+// Gid returns the gid field of the message.
+func (s *SetattrReq) Gid() uint32 { return s.gid }
+
+// Length returns the length field of the message.
+func (s *SetattrReq) Length() uint64 { return s.length }
+
+// AtimeSec returns the atimeSec field of the message.
+func (s *SetattrReq) AtimeSec() uint64 { return s.atimeSec }
+
+// AtimeNsec returns the atimeNsec field of the message.
+func (s *SetattrReq) AtimeNsec() uint64 { return s.atimeNsec }
+
+// MtimeSec returns the mtimeSec field of the message.
+func (s *SetattrReq) MtimeSec() uint64 { return s.mtimeSec }
+
+// MtimeNsec returns the mtimeNsec field of the message.
+func (s *SetattrReq) MtimeNsec() uint64 { return s.mtimeNsec }
+
+func (s *SetattrReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tsetattr, "fid", data, b, err)
+	}
+	if s.valid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tsetattr, "valid", data, b, err)
+	}
+	if s.mode, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tsetattr, "mode", data, b, err)
+	}
+	if s.uid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tsetattr, "uid", data, b, err)
+	}
+	if s.gid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tsetattr, "gid", data, b, err)
+	}
+	if s.length, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tsetattr, "length", data, b, err)
+	}
+	if s.atimeSec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tsetattr, "atimeSec", data, b, err)
+	}
+	if s.atimeNsec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tsetattr, "atimeNsec", data, b, err)
+	}
+	if s.mtimeSec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tsetattr, "mtimeSec", data, b, err)
+	}
+	if s.mtimeNsec, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tsetattr, "mtimeNsec", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tsetattr, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SetattrReq) Size() plan9.Size {
+	return 67
+}
+
+func (s *SetattrReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SetattrReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tsetattr, s.header.tag)
+	b = pfid(b, s.fid)
+	b = pbit32(b, s.valid)
+	b = pbit32(b, s.mode)
+	b = pbit32(b, s.uid)
+	b = pbit32(b, s.gid)
+	b = pbit64(b, s.length)
+	b = pbit64(b, s.atimeSec)
+	b = pbit64(b, s.atimeNsec)
+	b = pbit64(b, s.mtimeSec)
+	b = pbit64(b, s.mtimeNsec)
+	psize(b[n:])
+	return b, nil
+}
+
+// SetattrResp is a 9P Rsetattr message
+//
+// 	size[4] Rsetattr tag[2]
+//
+type SetattrResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (s *SetattrResp) Header() Header { return s.header }
+
+func (s *SetattrResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rsetattr, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SetattrResp) Size() plan9.Size {
+	return 7
+}
+
+func (s *SetattrResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SetattrResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rsetattr, s.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// XattrwalkReq is a 9P Txattrwalk message
+//
+// 	size[4] Txattrwalk tag[2] fid[4] newfid[4] name[s]
+//
+type XattrwalkReq struct {
+	header Header
+	fid    plan9.FID
+	newfid plan9.FID
+	name   string
+}
+
+// Header returns the header of the message.
+func (x *XattrwalkReq) Header() Header { return x.header }
+
+// Fid returns the fid field of the message.
+func (x *XattrwalkReq) Fid() plan9.FID { return x.fid }
+
+// Newfid returns the newfid field of the message.
+func (x *XattrwalkReq) Newfid() plan9.FID { return x.newfid }
+
+// Name returns the name field of the message.
+func (x *XattrwalkReq) Name() string { return x.name }
+
+func (x *XattrwalkReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if x.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Txattrwalk, "fid", data, b, err)
+	}
+	if x.newfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Txattrwalk, "newfid", data, b, err)
+	}
+	if x.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Txattrwalk, "name", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Txattrwalk, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (x *XattrwalkReq) Size() plan9.Size {
+	n := 17
+	n += len(x.name)
+	return plan9.Size(n)
+}
+
+func (x *XattrwalkReq) MarshalBinary() ([]byte, error) {
+	return x.appendBinary(make([]byte, 0, x.Size()))
+}
+
+func (x *XattrwalkReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Txattrwalk, x.header.tag)
+	b = pfid(b, x.fid)
+	b = pfid(b, x.newfid)
+	if b, err = pstring(b, x.name); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// XattrwalkResp is a 9P Rxattrwalk message
+//
+// 	size[4] Rxattrwalk tag[2] attrsize[8]
+//
+type XattrwalkResp struct {
+	header   Header
+	attrsize uint64
+}
+
+// Header returns the header of the message.
+func (x *XattrwalkResp) Header() Header { return x.header }
+
+// Attrsize returns the attrsize field of the message.
+func (x *XattrwalkResp) Attrsize() uint64 { return x.attrsize }
+
+func (x *XattrwalkResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if x.attrsize, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rxattrwalk, "attrsize", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rxattrwalk, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (x *XattrwalkResp) Size() plan9.Size {
+	return 15
+}
+
+func (x *XattrwalkResp) MarshalBinary() ([]byte, error) {
+	return x.appendBinary(make([]byte, 0, x.Size()))
+}
+
+func (x *XattrwalkResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rxattrwalk, x.header.tag)
+	b = pbit64(b, x.attrsize)
+	psize(b[n:])
+	return b, nil
+}
+
+// XattrcreateReq is a 9P Txattrcreate message
+//
+// 	size[4] Txattrcreate tag[2] fid[4] name[s] attr_size[8] flags[4]
+//
+type XattrcreateReq struct {
+	header   Header
+	fid      plan9.FID
+	name     string
+	attrSize uint64
+	flags    uint32
+}
+
+// Header returns the header of the message.
+func (x *XattrcreateReq) Header() Header { return x.header }
+
+// Fid returns the fid field of the message.
+func (x *XattrcreateReq) Fid() plan9.FID { return x.fid }
+
+// Name returns the name field of the message.
+func (x *XattrcreateReq) Name() string { return x.name }
+
+// AttrSize returns the attrSize field of the message.
+func (x *XattrcreateReq) AttrSize() uint64 { return x.attrSize }
+
+// Flags returns the flags field of the message.
+func (x *XattrcreateReq) Flags() uint32 { return x.flags }
+
+func (x *XattrcreateReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if x.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Txattrcreate, "fid", data, b, err)
+	}
+	if x.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Txattrcreate, "name", data, b, err)
+	}
+	if x.attrSize, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Txattrcreate, "attrSize", data, b, err)
+	}
+	if x.flags, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Txattrcreate, "flags", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Txattrcreate, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (x *XattrcreateReq) Size() plan9.Size {
+	n := 25
+	n += len(x.name)
+	return plan9.Size(n)
+}
+
+func (x *XattrcreateReq) MarshalBinary() ([]byte, error) {
+	return x.appendBinary(make([]byte, 0, x.Size()))
+}
+
+func (x *XattrcreateReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Txattrcreate, x.header.tag)
+	b = pfid(b, x.fid)
+	if b, err = pstring(b, x.name); err != nil {
+		return nil, err
+	}
+	b = pbit64(b, x.attrSize)
+	b = pbit32(b, x.flags)
+	psize(b[n:])
+	return b, nil
+}
+
+// XattrcreateResp is a 9P Rxattrcreate message
+//
+// 	size[4] Rxattrcreate tag[2]
+//
+type XattrcreateResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (x *XattrcreateResp) Header() Header { return x.header }
+
+func (x *XattrcreateResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rxattrcreate, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (x *XattrcreateResp) Size() plan9.Size {
+	return 7
+}
+
+func (x *XattrcreateResp) MarshalBinary() ([]byte, error) {
+	return x.appendBinary(make([]byte, 0, x.Size()))
+}
+
+func (x *XattrcreateResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rxattrcreate, x.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// ReaddirReq is a 9P Treaddir message
+//
+// 	size[4] Treaddir tag[2] fid[4] offset[8] count[4]
+//
+type ReaddirReq struct {
+	header Header
+	fid    plan9.FID
+	offset uint64
+	count  uint32
+}
+
+// Header returns the header of the message.
+func (r *ReaddirReq) Header() Header { return r.header }
+
+// Fid returns the fid field of the message.
+func (r *ReaddirReq) Fid() plan9.FID { return r.fid }
+
+// Offset returns the offset field of the message.
+func (r *ReaddirReq) Offset() uint64 { return r.offset }
+
+// Count returns the count field of the message.
+func (r *ReaddirReq) Count() uint32 { return r.count }
+
+func (r *ReaddirReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if r.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Treaddir, "fid", data, b, err)
+	}
+	if r.offset, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Treaddir, "offset", data, b, err)
+	}
+	if r.count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Treaddir, "count", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Treaddir, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *ReaddirReq) Size() plan9.Size {
+	return 23
+}
+
+func (r *ReaddirReq) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *ReaddirReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Treaddir, r.header.tag)
+	b = pfid(b, r.fid)
+	b = pbit64(b, r.offset)
+	b = pbit32(b, r.count)
+	psize(b[n:])
+	return b, nil
+}
+
+// ReaddirResp is a 9P Rreaddir message
+//
+// 	size[4] Rreaddir tag[2] count[4] data[count]
+//
+type ReaddirResp struct {
+	header Header
+	data   []byte
+}
+
+// Header returns the header of the message.
+func (r *ReaddirResp) Header() Header { return r.header }
+
+// Data returns the data field of the message.
+func (r *ReaddirResp) Data() []byte { return r.data }
+
+func (r *ReaddirResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	var count uint32
+	if count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rreaddir, "count", data, b, err)
+	}
+	if r.data, b, err = gdata(b, count); err != nil {
+		return decodeError(plan9.Rreaddir, "data", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rreaddir, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *ReaddirResp) Size() plan9.Size {
+	n := 11
+	n += len(r.data)
+	return plan9.Size(n)
+}
+
+func (r *ReaddirResp) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *ReaddirResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rreaddir, r.header.tag)
+	b = pbit32(b, uint32(len(r.data)))
+	b = append(b, r.data...)
+	psize(b[n:])
+	return b, nil
+}
+
+// FsyncReq is a 9P Tfsync message
+//
+// 	size[4] Tfsync tag[2] fid[4] datasync[4]
+//
+type FsyncReq struct {
+	header   Header
+	fid      plan9.FID
+	datasync uint32
+}
+
+// Header returns the header of the message.
+func (f *FsyncReq) Header() Header { return f.header }
+
+// Fid returns the fid field of the message.
+func (f *FsyncReq) Fid() plan9.FID { return f.fid }
+
+// Datasync returns the datasync field of the message.
+func (f *FsyncReq) Datasync() uint32 { return f.datasync }
+
+func (f *FsyncReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if f.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tfsync, "fid", data, b, err)
+	}
+	if f.datasync, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tfsync, "datasync", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tfsync, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (f *FsyncReq) Size() plan9.Size {
+	return 15
+}
+
+func (f *FsyncReq) MarshalBinary() ([]byte, error) {
+	return f.appendBinary(make([]byte, 0, f.Size()))
+}
+
+func (f *FsyncReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tfsync, f.header.tag)
+	b = pfid(b, f.fid)
+	b = pbit32(b, f.datasync)
+	psize(b[n:])
+	return b, nil
+}
+
+// FsyncResp is a 9P Rfsync message
+//
+// 	size[4] Rfsync tag[2]
+//
+type FsyncResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (f *FsyncResp) Header() Header { return f.header }
+
+func (f *FsyncResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rfsync, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (f *FsyncResp) Size() plan9.Size {
+	return 7
+}
+
+func (f *FsyncResp) MarshalBinary() ([]byte, error) {
+	return f.appendBinary(make([]byte, 0, f.Size()))
+}
+
+func (f *FsyncResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rfsync, f.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// LockReq is a 9P Tlock message
+//
+// 	size[4] Tlock tag[2] fid[4] locktype[1] flags[4] start[8] length[8] proc_id[4] client_id[s]
+//
+type LockReq struct {
+	header   Header
+	fid      plan9.FID
+	locktype uint8
+	flags    uint32
+	start    uint64
+	length   uint64
+	procID   uint32
+	clientID string
+}
+
+// Header returns the header of the message.
+func (l *LockReq) Header() Header { return l.header }
+
+// Fid returns the fid field of the message.
+func (l *LockReq) Fid() plan9.FID { return l.fid }
+
+// Locktype returns the locktype field of the message.
+func (l *LockReq) Locktype() uint8 { return l.locktype }
+
+// Flags returns the flags field of the message.
+func (l *LockReq) Flags() uint32 { return l.flags }
+
+// Start returns the start field of the message.
+func (l *LockReq) Start() uint64 { return l.start }
+
+// Length returns the length field of the message.
+func (l *LockReq) Length() uint64 { return l.length }
+
+// ProcID returns the procID field of the message.
+func (l *LockReq) ProcID() uint32 { return l.procID }
+
+// ClientID returns the clientID field of the message.
+func (l *LockReq) ClientID() string { return l.clientID }
+
+func (l *LockReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tlock, "fid", data, b, err)
+	}
+	if l.locktype, b, err = guint8(b); err != nil {
+		return decodeError(plan9.Tlock, "locktype", data, b, err)
+	}
+	if l.flags, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tlock, "flags", data, b, err)
+	}
+	if l.start, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tlock, "start", data, b, err)
+	}
+	if l.length, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tlock, "length", data, b, err)
+	}
+	if l.procID, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tlock, "procID", data, b, err)
+	}
+	if l.clientID, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tlock, "clientID", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tlock, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LockReq) Size() plan9.Size {
+	n := 38
+	n += len(l.clientID)
+	return plan9.Size(n)
+}
+
+func (l *LockReq) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LockReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tlock, l.header.tag)
+	b = pfid(b, l.fid)
+	b = pbit8(b, l.locktype)
+	b = pbit32(b, l.flags)
+	b = pbit64(b, l.start)
+	b = pbit64(b, l.length)
+	b = pbit32(b, l.procID)
+	if b, err = pstring(b, l.clientID); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// LockResp is a 9P Rlock message
+//
+// 	size[4] Rlock tag[2] status[1]
+//
+type LockResp struct {
+	header Header
+	status uint8
+}
+
+// Header returns the header of the message.
+func (l *LockResp) Header() Header { return l.header }
+
+// Status returns the status field of the message.
+func (l *LockResp) Status() uint8 { return l.status }
+
+func (l *LockResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.status, b, err = guint8(b); err != nil {
+		return decodeError(plan9.Rlock, "status", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rlock, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LockResp) Size() plan9.Size {
+	return 8
+}
+
+func (l *LockResp) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LockResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rlock, l.header.tag)
+	b = pbit8(b, l.status)
+	psize(b[n:])
+	return b, nil
+}
+
+// GetlockReq is a 9P Tgetlock message
+//
+// 	size[4] Tgetlock tag[2] fid[4] locktype[1] start[8] length[8] proc_id[4] client_id[s]
+//
+type GetlockReq struct {
+	header   Header
+	fid      plan9.FID
+	locktype uint8
+	start    uint64
+	length   uint64
+	procID   uint32
+	clientID string
+}
+
+// Header returns the header of the message.
+func (g *GetlockReq) Header() Header { return g.header }
+
+// Fid returns the fid field of the message.
+func (g *GetlockReq) Fid() plan9.FID { return g.fid }
+
+// Locktype returns the locktype field of the message.
+func (g *GetlockReq) Locktype() uint8 { return g.locktype }
+
+// Start returns the start field of the message.
+func (g *GetlockReq) Start() uint64 { return g.start }
+
+// Length returns the length field of the message.
+func (g *GetlockReq) Length() uint64 { return g.length }
+
+// ProcID returns the procID field of the message.
+func (g *GetlockReq) ProcID() uint32 { return g.procID }
+
+// ClientID returns the clientID field of the message.
+func (g *GetlockReq) ClientID() string { return g.clientID }
+
+func (g *GetlockReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if g.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tgetlock, "fid", data, b, err)
+	}
+	if g.locktype, b, err = guint8(b); err != nil {
+		return decodeError(plan9.Tgetlock, "locktype", data, b, err)
+	}
+	if g.start, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tgetlock, "start", data, b, err)
+	}
+	if g.length, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tgetlock, "length", data, b, err)
+	}
+	if g.procID, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tgetlock, "procID", data, b, err)
+	}
+	if g.clientID, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tgetlock, "clientID", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tgetlock, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (g *GetlockReq) Size() plan9.Size {
+	n := 34
+	n += len(g.clientID)
+	return plan9.Size(n)
+}
+
+func (g *GetlockReq) MarshalBinary() ([]byte, error) {
+	return g.appendBinary(make([]byte, 0, g.Size()))
+}
+
+func (g *GetlockReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tgetlock, g.header.tag)
+	b = pfid(b, g.fid)
+	b = pbit8(b, g.locktype)
+	b = pbit64(b, g.start)
+	b = pbit64(b, g.length)
+	b = pbit32(b, g.procID)
+	if b, err = pstring(b, g.clientID); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// GetlockResp is a 9P Rgetlock message
+//
+// 	size[4] Rgetlock tag[2] locktype[1] start[8] length[8] proc_id[4] client_id[s]
+//
+type GetlockResp struct {
+	header   Header
+	locktype uint8
+	start    uint64
+	length   uint64
+	procID   uint32
+	clientID string
+}
+
+// Header returns the header of the message.
+func (g *GetlockResp) Header() Header { return g.header }
+
+// Locktype returns the locktype field of the message.
+func (g *GetlockResp) Locktype() uint8 { return g.locktype }
+
+// Start returns the start field of the message.
+func (g *GetlockResp) Start() uint64 { return g.start }
+
+// Length returns the length field of the message.
+func (g *GetlockResp) Length() uint64 { return g.length }
+
+// ProcID returns the procID field of the message.
+func (g *GetlockResp) ProcID() uint32 { return g.procID }
+
+// ClientID returns the clientID field of the message.
+func (g *GetlockResp) ClientID() string { return g.clientID }
+
+func (g *GetlockResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if g.locktype, b, err = guint8(b); err != nil {
+		return decodeError(plan9.Rgetlock, "locktype", data, b, err)
+	}
+	if g.start, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetlock, "start", data, b, err)
+	}
+	if g.length, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Rgetlock, "length", data, b, err)
+	}
+	if g.procID, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rgetlock, "procID", data, b, err)
+	}
+	if g.clientID, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Rgetlock, "clientID", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rgetlock, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (g *GetlockResp) Size() plan9.Size {
+	n := 30
+	n += len(g.clientID)
+	return plan9.Size(n)
+}
+
+func (g *GetlockResp) MarshalBinary() ([]byte, error) {
+	return g.appendBinary(make([]byte, 0, g.Size()))
+}
+
+func (g *GetlockResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Rgetlock, g.header.tag)
+	b = pbit8(b, g.locktype)
+	b = pbit64(b, g.start)
+	b = pbit64(b, g.length)
+	b = pbit32(b, g.procID)
+	if b, err = pstring(b, g.clientID); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// LinkReq is a 9P Tlink message
+//
+// 	size[4] Tlink tag[2] dfid[4] fid[4] name[s]
+//
+type LinkReq struct {
+	header Header
+	dfid   plan9.FID
+	fid    plan9.FID
+	name   string
+}
+
+// Header returns the header of the message.
+func (l *LinkReq) Header() Header { return l.header }
+
+// Dfid returns the dfid field of the message.
+func (l *LinkReq) Dfid() plan9.FID { return l.dfid }
+
+// Fid returns the fid field of the message.
+func (l *LinkReq) Fid() plan9.FID { return l.fid }
+
+// Name returns the name field of the message.
+func (l *LinkReq) Name() string { return l.name }
+
+func (l *LinkReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if l.dfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tlink, "dfid", data, b, err)
+	}
+	if l.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tlink, "fid", data, b, err)
+	}
+	if l.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tlink, "name", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tlink, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LinkReq) Size() plan9.Size {
+	n := 17
+	n += len(l.name)
+	return plan9.Size(n)
+}
+
+func (l *LinkReq) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LinkReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tlink, l.header.tag)
+	b = pfid(b, l.dfid)
+	b = pfid(b, l.fid)
+	if b, err = pstring(b, l.name); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// LinkResp is a 9P Rlink message
+//
+// 	size[4] Rlink tag[2]
+//
+type LinkResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (l *LinkResp) Header() Header { return l.header }
+
+func (l *LinkResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rlink, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (l *LinkResp) Size() plan9.Size {
+	return 7
+}
+
+func (l *LinkResp) MarshalBinary() ([]byte, error) {
+	return l.appendBinary(make([]byte, 0, l.Size()))
+}
+
+func (l *LinkResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rlink, l.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// MkdirReq is a 9P Tmkdir message
+//
+// 	size[4] Tmkdir tag[2] dfid[4] name[s] mode[4] gid[4]
+//
+type MkdirReq struct {
+	header Header
+	dfid   plan9.FID
+	name   string
+	mode   uint32
+	gid    uint32
+}
+
+// Header returns the header of the message.
+func (m *MkdirReq) Header() Header { return m.header }
+
+// Dfid returns the dfid field of the message.
+func (m *MkdirReq) Dfid() plan9.FID { return m.dfid }
+
+// Name returns the name field of the message.
+func (m *MkdirReq) Name() string { return m.name }
+
+// Mode returns the mode field of the message.
+func (m *MkdirReq) Mode() uint32 { return m.mode }
+
+// Gid returns the gid field of the message.
+func (m *MkdirReq) Gid() uint32 { return m.gid }
+
+func (m *MkdirReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if m.dfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tmkdir, "dfid", data, b, err)
+	}
+	if m.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tmkdir, "name", data, b, err)
+	}
+	if m.mode, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tmkdir, "mode", data, b, err)
+	}
+	if m.gid, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tmkdir, "gid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tmkdir, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (m *MkdirReq) Size() plan9.Size {
+	n := 21
+	n += len(m.name)
+	return plan9.Size(n)
+}
+
+func (m *MkdirReq) MarshalBinary() ([]byte, error) {
+	return m.appendBinary(make([]byte, 0, m.Size()))
+}
+
+func (m *MkdirReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tmkdir, m.header.tag)
+	b = pfid(b, m.dfid)
+	if b, err = pstring(b, m.name); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, m.mode)
+	b = pbit32(b, m.gid)
+	psize(b[n:])
+	return b, nil
+}
+
+// MkdirResp is a 9P Rmkdir message
+//
+// 	size[4] Rmkdir tag[2] qid[13]
+//
+type MkdirResp struct {
+	header Header
+	qid    plan9.QID
+}
+
+// Header returns the header of the message.
+func (m *MkdirResp) Header() Header { return m.header }
+
+// Qid returns the qid field of the message.
+func (m *MkdirResp) Qid() plan9.QID { return m.qid }
+
+func (m *MkdirResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if m.qid, b, err = gqid(b); err != nil {
+		return decodeError(plan9.Rmkdir, "qid", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rmkdir, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (m *MkdirResp) Size() plan9.Size {
+	return 20
+}
+
+func (m *MkdirResp) MarshalBinary() ([]byte, error) {
+	return m.appendBinary(make([]byte, 0, m.Size()))
+}
+
+func (m *MkdirResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rmkdir, m.header.tag)
+	b = pqid(b, m.qid)
+	psize(b[n:])
+	return b, nil
+}
+
+// RenameatReq is a 9P Trenameat message
+//
+// 	size[4] Trenameat tag[2] olddirfid[4] oldname[s] newdirfid[4] newname[s]
+//
+type RenameatReq struct {
+	header    Header
+	olddirfid plan9.FID
+	oldname   string
+	newdirfid plan9.FID
+	newname   string
+}
+
+// Header returns the header of the message.
+func (r *RenameatReq) Header() Header { return r.header }
+
+// Olddirfid returns the olddirfid field of the message.
+func (r *RenameatReq) Olddirfid() plan9.FID { return r.olddirfid }
+
+// Oldname returns the oldname field of the message.
+func (r *RenameatReq) Oldname() string { return r.oldname }
+
+// Newdirfid returns the newdirfid field of the message.
+func (r *RenameatReq) Newdirfid() plan9.FID { return r.newdirfid }
+
+// Newname returns the newname field of the message.
+func (r *RenameatReq) Newname() string { return r.newname }
+
+func (r *RenameatReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if r.olddirfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Trenameat, "olddirfid", data, b, err)
+	}
+	if r.oldname, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Trenameat, "oldname", data, b, err)
+	}
+	if r.newdirfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Trenameat, "newdirfid", data, b, err)
+	}
+	if r.newname, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Trenameat, "newname", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Trenameat, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *RenameatReq) Size() plan9.Size {
+	n := 19
+	n += len(r.oldname)
+	n += len(r.newname)
+	return plan9.Size(n)
+}
+
+func (r *RenameatReq) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *RenameatReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Trenameat, r.header.tag)
+	b = pfid(b, r.olddirfid)
+	if b, err = pstring(b, r.oldname); err != nil {
+		return nil, err
+	}
+	b = pfid(b, r.newdirfid)
+	if b, err = pstring(b, r.newname); err != nil {
+		return nil, err
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// RenameatResp is a 9P Rrenameat message
+//
+// 	size[4] Rrenameat tag[2]
+//
+type RenameatResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (r *RenameatResp) Header() Header { return r.header }
+
+func (r *RenameatResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rrenameat, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (r *RenameatResp) Size() plan9.Size {
+	return 7
+}
+
+func (r *RenameatResp) MarshalBinary() ([]byte, error) {
+	return r.appendBinary(make([]byte, 0, r.Size()))
+}
+
+func (r *RenameatResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rrenameat, r.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// UnlinkatReq is a 9P Tunlinkat message
+//
+// 	size[4] Tunlinkat tag[2] dirfid[4] name[s] flags[4]
+//
+type UnlinkatReq struct {
+	header Header
+	dirfid plan9.FID
+	name   string
+	flags  uint32
+}
+
+// Header returns the header of the message.
+func (u *UnlinkatReq) Header() Header { return u.header }
+
+// Dirfid returns the dirfid field of the message.
+func (u *UnlinkatReq) Dirfid() plan9.FID { return u.dirfid }
+
+// Name returns the name field of the message.
+func (u *UnlinkatReq) Name() string { return u.name }
+
+// Flags returns the flags field of the message.
+func (u *UnlinkatReq) Flags() uint32 { return u.flags }
+
+func (u *UnlinkatReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if u.dirfid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tunlinkat, "dirfid", data, b, err)
+	}
+	if u.name, b, err = gstring(b); err != nil {
+		return decodeError(plan9.Tunlinkat, "name", data, b, err)
+	}
+	if u.flags, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tunlinkat, "flags", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tunlinkat, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (u *UnlinkatReq) Size() plan9.Size {
+	n := 17
+	n += len(u.name)
+	return plan9.Size(n)
+}
+
+func (u *UnlinkatReq) MarshalBinary() ([]byte, error) {
+	return u.appendBinary(make([]byte, 0, u.Size()))
+}
+
+func (u *UnlinkatReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tunlinkat, u.header.tag)
+	b = pfid(b, u.dirfid)
+	if b, err = pstring(b, u.name); err != nil {
+		return nil, err
+	}
+	b = pbit32(b, u.flags)
+	psize(b[n:])
+	return b, nil
+}
+
+// UnlinkatResp is a 9P Runlinkat message
+//
+// 	size[4] Runlinkat tag[2]
+//
+type UnlinkatResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (u *UnlinkatResp) Header() Header { return u.header }
+
+func (u *UnlinkatResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Runlinkat, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (u *UnlinkatResp) Size() plan9.Size {
+	return 7
+}
+
+func (u *UnlinkatResp) MarshalBinary() ([]byte, error) {
+	return u.appendBinary(make([]byte, 0, u.Size()))
+}
+
+func (u *UnlinkatResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Runlinkat, u.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// SessionReq is a 9P Tsession message
+//
+// 	size[4] Tsession tag[2] key[8]
+//
+type SessionReq struct {
+	header Header
+	key    uint64
+}
+
+// Header returns the header of the message.
+func (s *SessionReq) Header() Header { return s.header }
+
+// Key returns the key field of the message.
+func (s *SessionReq) Key() uint64 { return s.key }
+
+func (s *SessionReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.key, b, err = guint64(b); err != nil {
+		return decodeError(plan9.Tsession, "key", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tsession, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SessionReq) Size() plan9.Size {
+	return 15
+}
+
+func (s *SessionReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SessionReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Tsession, s.header.tag)
+	b = pbit64(b, s.key)
+	psize(b[n:])
+	return b, nil
+}
+
+// SessionResp is a 9P Rsession message
+//
+// 	size[4] Rsession tag[2]
+//
+type SessionResp struct {
+	header Header
+}
+
+// Header returns the header of the message.
+func (s *SessionResp) Header() Header { return s.header }
+
+func (s *SessionResp) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return decodeError(plan9.Rsession, "size", data, data, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SessionResp) Size() plan9.Size {
+	return 7
+}
+
+func (s *SessionResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SessionResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rsession, s.header.tag)
+	psize(b[n:])
+	return b, nil
+}
+
+// SreadReq is a 9P Tsread message
+//
+// 	size[4] Tsread tag[2] fid[4] nwname[2] nwname*(wname[s])
+//
+type SreadReq struct {
+	header Header
+	fid    plan9.FID
+	wname  []string
+}
+
+// Header returns the header of the message.
+func (s *SreadReq) Header() Header { return s.header }
+
+// Fid returns the fid field of the message.
+func (s *SreadReq) Fid() plan9.FID { return s.fid }
+
+// Wname returns the wname field of the message.
+func (s *SreadReq) Wname() []string { return s.wname }
+
+func (s *SreadReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tsread, "fid", data, b, err)
+	}
+	var n uint16
+	if n, b, err = guint16(b); err != nil {
+		return decodeError(plan9.Tsread, "nwname", data, b, err)
+	}
+	for i := uint16(0); i < n; i++ {
+		var x string
+		if x, b, err = gstring(b); err != nil {
+			return decodeError(plan9.Tsread, "wname", data, b, err)
+		}
+		s.wname = append(s.wname, x)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tsread, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SreadReq) Size() plan9.Size {
+	n := 13
+	for _, x := range s.wname {
+		n += 2 + len(x)
+	}
+	return plan9.Size(n)
+}
+
+func (s *SreadReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SreadReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tsread, s.header.tag)
+	b = pfid(b, s.fid)
+	b = pbit16(b, uint16(len(s.wname)))
+	for _, x := range s.wname {
+		if b, err = pstring(b, x); err != nil {
+			return nil, err
+		}
+	}
+	psize(b[n:])
+	return b, nil
+}
+
+// SreadResp is a 9P Rsread message
+//
+// 	size[4] Rsread tag[2] count[4] data[count]
+//
+type SreadResp struct {
+	header Header
+	data   []byte
+}
+
+// Header returns the header of the message.
+func (s *SreadResp) Header() Header { return s.header }
+
+// Data returns the data field of the message.
+func (s *SreadResp) Data() []byte { return s.data }
+
+func (s *SreadResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	var count uint32
+	if count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rsread, "count", data, b, err)
+	}
+	if s.data, b, err = gdata(b, count); err != nil {
+		return decodeError(plan9.Rsread, "data", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rsread, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SreadResp) Size() plan9.Size {
+	n := 11
+	n += len(s.data)
+	return plan9.Size(n)
+}
+
+func (s *SreadResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SreadResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rsread, s.header.tag)
+	b = pbit32(b, uint32(len(s.data)))
+	b = append(b, s.data...)
+	psize(b[n:])
+	return b, nil
+}
+
+// SwriteReq is a 9P Tswrite message
+//
+// 	size[4] Tswrite tag[2] fid[4] nwname[2] nwname*(wname[s]) count[4] data[count]
+//
+type SwriteReq struct {
+	header Header
+	fid    plan9.FID
+	wname  []string
+	data   []byte
+}
+
+// Header returns the header of the message.
+func (s *SwriteReq) Header() Header { return s.header }
+
+// Fid returns the fid field of the message.
+func (s *SwriteReq) Fid() plan9.FID { return s.fid }
+
+// Wname returns the wname field of the message.
+func (s *SwriteReq) Wname() []string { return s.wname }
+
+// Data returns the data field of the message.
+func (s *SwriteReq) Data() []byte { return s.data }
+
+func (s *SwriteReq) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.fid, b, err = gfid(b); err != nil {
+		return decodeError(plan9.Tswrite, "fid", data, b, err)
+	}
+	var n uint16
+	if n, b, err = guint16(b); err != nil {
+		return decodeError(plan9.Tswrite, "nwname", data, b, err)
+	}
+	for i := uint16(0); i < n; i++ {
+		var x string
+		if x, b, err = gstring(b); err != nil {
+			return decodeError(plan9.Tswrite, "wname", data, b, err)
+		}
+		s.wname = append(s.wname, x)
+	}
+	var count uint32
+	if count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Tswrite, "count", data, b, err)
+	}
+	if s.data, b, err = gdata(b, count); err != nil {
+		return decodeError(plan9.Tswrite, "data", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Tswrite, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SwriteReq) Size() plan9.Size {
+	n := 17
+	for _, x := range s.wname {
+		n += 2 + len(x)
+	}
+	n += len(s.data)
+	return plan9.Size(n)
+}
+
+func (s *SwriteReq) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SwriteReq) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	var err error
+	b = pheader(b, plan9.Tswrite, s.header.tag)
+	b = pfid(b, s.fid)
+	b = pbit16(b, uint16(len(s.wname)))
+	for _, x := range s.wname {
+		if b, err = pstring(b, x); err != nil {
+			return nil, err
+		}
+	}
+	b = pbit32(b, uint32(len(s.data)))
+	b = append(b, s.data...)
+	psize(b[n:])
+	return b, nil
+}
+
+// SwriteResp is a 9P Rswrite message
+//
+// 	size[4] Rswrite tag[2] count[4]
+//
+type SwriteResp struct {
+	header Header
+	count  uint32
+}
+
+// Header returns the header of the message.
+func (s *SwriteResp) Header() Header { return s.header }
+
+// Count returns the count field of the message.
+func (s *SwriteResp) Count() uint32 { return s.count }
+
+func (s *SwriteResp) UnmarshalBinary(data []byte) error {
+	var err error
+	b := data
+	if s.count, b, err = guint32(b); err != nil {
+		return decodeError(plan9.Rswrite, "count", data, b, err)
+	}
+	if len(b) != 0 {
+		return decodeError(plan9.Rswrite, "size", data, b, ErrTrailingData)
+	}
+	return nil
+}
+
+// Size returns the size of the message on the wire.
+func (s *SwriteResp) Size() plan9.Size {
+	return 11
+}
+
+func (s *SwriteResp) MarshalBinary() ([]byte, error) {
+	return s.appendBinary(make([]byte, 0, s.Size()))
+}
+
+func (s *SwriteResp) appendBinary(b []byte) ([]byte, error) {
+	n := len(b)
+	b = pheader(b, plan9.Rswrite, s.header.tag)
+	b = pbit32(b, s.count)
+	psize(b[n:])
+	return b, nil
+}
+
+func newMessage(h Header) Message {
+	var msg Message
+	switch h.mtype {
+	case plan9.Tversion:
+		return &VersionReq{header: h}
+	case plan9.Rversion:
+		return &VersionResp{header: h}
+	case plan9.Tauth:
+		return &AuthReq{header: h}
+	case plan9.Rauth:
+		return &AuthResp{header: h}
+	case plan9.Rerror:
+		return &ErrorResp{header: h}
+	case plan9.Tflush:
+		return &FlushReq{header: h}
+	case plan9.Rflush:
+		return &FlushResp{header: h}
+	case plan9.Tattach:
+		return &AttachReq{header: h}
+	case plan9.Rattach:
+		return &AttachResp{header: h}
+	case plan9.Twalk:
+		return &WalkReq{header: h}
+	case plan9.Rwalk:
+		return &WalkResp{header: h}
+	case plan9.Topen:
+		return &OpenReq{header: h}
+	case plan9.Ropen:
+		return &OpenResp{header: h}
+	case plan9.Tcreate:
+		return &CreateReq{header: h}
+	case plan9.Rcreate:
+		return &CreateResp{header: h}
+	case plan9.Tread:
+		return &ReadReq{header: h}
+	case plan9.Rread:
+		return &ReadResp{header: h}
+	case plan9.Twrite:
+		return &WriteReq{header: h}
+	case plan9.Rwrite:
+		return &WriteResp{header: h}
+	case plan9.Tclunk:
+		return &ClunkReq{header: h}
+	case plan9.Rclunk:
+		return &ClunkResp{header: h}
+	case plan9.Tremove:
+		return &RemoveReq{header: h}
+	case plan9.Rremove:
+		return &RemoveResp{header: h}
+	case plan9.Tstat:
+		return &StatReq{header: h}
+	case plan9.Rstat:
+		return &StatResp{header: h}
+	case plan9.Twstat:
+		return &WstatReq{header: h}
+	case plan9.Rwstat:
+		return &WstatResp{header: h}
+	case plan9.Rlerror:
+		return &LerrorResp{header: h}
+	case plan9.Tstatfs:
+		return &StatfsReq{header: h}
+	case plan9.Rstatfs:
+		return &StatfsResp{header: h}
+	case plan9.Tlopen:
+		return &LopenReq{header: h}
+	case plan9.Rlopen:
+		return &LopenResp{header: h}
+	case plan9.Tlcreate:
+		return &LcreateReq{header: h}
+	case plan9.Rlcreate:
+		return &LcreateResp{header: h}
+	case plan9.Tsymlink:
+		return &SymlinkReq{header: h}
+	case plan9.Rsymlink:
+		return &SymlinkResp{header: h}
+	case plan9.Tmknod:
+		return &MknodReq{header: h}
+	case plan9.Rmknod:
+		return &MknodResp{header: h}
+	case plan9.Trename:
+		return &RenameReq{header: h}
+	case plan9.Rrename:
+		return &RenameResp{header: h}
+	case plan9.Treadlink:
+		return &ReadlinkReq{header: h}
+	case plan9.Rreadlink:
+		return &ReadlinkResp{header: h}
+	case plan9.Tgetattr:
+		return &GetattrReq{header: h}
+	case plan9.Rgetattr:
+		return &GetattrResp{header: h}
+	case plan9.Tsetattr:
+		return &SetattrReq{header: h}
+	case plan9.Rsetattr:
+		return &SetattrResp{header: h}
+	case plan9.Txattrwalk:
+		return &XattrwalkReq{header: h}
+	case plan9.Rxattrwalk:
+		return &XattrwalkResp{header: h}
+	case plan9.Txattrcreate:
+		return &XattrcreateReq{header: h}
+	case plan9.Rxattrcreate:
+		return &XattrcreateResp{header: h}
+	case plan9.Treaddir:
+		return &ReaddirReq{header: h}
+	case plan9.Rreaddir:
+		return &ReaddirResp{header: h}
+	case plan9.Tfsync:
+		return &FsyncReq{header: h}
+	case plan9.Rfsync:
+		return &FsyncResp{header: h}
+	case plan9.Tlock:
+		return &LockReq{header: h}
+	case plan9.Rlock:
+		return &LockResp{header: h}
+	case plan9.Tgetlock:
+		return &GetlockReq{header: h}
+	case plan9.Rgetlock:
+		return &GetlockResp{header: h}
+	case plan9.Tlink:
+		return &LinkReq{header: h}
+	case plan9.Rlink:
+		return &LinkResp{header: h}
+	case plan9.Tmkdir:
+		return &MkdirReq{header: h}
+	case plan9.Rmkdir:
+		return &MkdirResp{header: h}
+	case plan9.Trenameat:
+		return &RenameatReq{header: h}
+	case plan9.Rrenameat:
+		return &RenameatResp{header: h}
+	case plan9.Tunlinkat:
+		return &UnlinkatReq{header: h}
+	case plan9.Runlinkat:
+		return &UnlinkatResp{header: h}
+	case plan9.Tsession:
+		return &SessionReq{header: h}
+	case plan9.Rsession:
+		return &SessionResp{header: h}
+	case plan9.Tsread:
+		return &SreadReq{header: h}
+	case plan9.Rsread:
+		return &SreadResp{header: h}
+	case plan9.Tswrite:
+		return &SwriteReq{header: h}
+	case plan9.Rswrite:
+		return &SwriteResp{header: h}
+	}
+	return msg
+}
+
+// Tversion writes a Tversion message.
+func (e *Encoder) Tversion(tag plan9.Tag, msize uint32, version string) error {
+	m := VersionReq{header: Header{tag: tag, dialect: e.dialect}, msize: msize, version: version}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rversion writes an Rversion message.
+func (e *Encoder) Rversion(tag plan9.Tag, msize uint32, version string) error {
+	m := VersionResp{header: Header{tag: tag, dialect: e.dialect}, msize: msize, version: version}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tauth writes a Tauth message.
+func (e *Encoder) Tauth(tag plan9.Tag, afid plan9.FID, uname string, aname string, nUname uint32) error {
+	m := AuthReq{header: Header{tag: tag, dialect: e.dialect}, afid: afid, uname: uname, aname: aname, nUname: nUname}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rauth writes an Rauth message.
+func (e *Encoder) Rauth(tag plan9.Tag, aqid plan9.QID) error {
+	m := AuthResp{header: Header{tag: tag, dialect: e.dialect}, aqid: aqid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rerror writes an Rerror message.
+func (e *Encoder) Rerror(tag plan9.Tag, ename string, errno uint32) error {
+	m := ErrorResp{header: Header{tag: tag, dialect: e.dialect}, ename: ename, errno: errno}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tflush writes a Tflush message.
+func (e *Encoder) Tflush(tag plan9.Tag, oldtag plan9.Tag) error {
+	m := FlushReq{header: Header{tag: tag, dialect: e.dialect}, oldtag: oldtag}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rflush writes an Rflush message.
+func (e *Encoder) Rflush(tag plan9.Tag) error {
+	m := FlushResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tattach writes a Tattach message.
+func (e *Encoder) Tattach(tag plan9.Tag, fid plan9.FID, afid plan9.FID, uname string, aname string, nUname uint32) error {
+	m := AttachReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, afid: afid, uname: uname, aname: aname, nUname: nUname}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rattach writes an Rattach message.
+func (e *Encoder) Rattach(tag plan9.Tag, qid plan9.QID) error {
+	m := AttachResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Twalk writes a Twalk message.
+func (e *Encoder) Twalk(tag plan9.Tag, fid plan9.FID, newfid plan9.FID, wname []string) error {
+	m := WalkReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, newfid: newfid, wname: wname}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rwalk writes an Rwalk message.
+func (e *Encoder) Rwalk(tag plan9.Tag, wqid []plan9.QID) error {
+	m := WalkResp{header: Header{tag: tag, dialect: e.dialect}, wqid: wqid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Topen writes a Topen message.
+func (e *Encoder) Topen(tag plan9.Tag, fid plan9.FID, mode uint8) error {
+	m := OpenReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, mode: mode}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Ropen writes an Ropen message.
+func (e *Encoder) Ropen(tag plan9.Tag, qid plan9.QID, iounit uint32) error {
+	m := OpenResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid, iounit: iounit}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tcreate writes a Tcreate message.
+func (e *Encoder) Tcreate(tag plan9.Tag, fid plan9.FID, name string, perm uint32, mode uint8, extension string) error {
+	m := CreateReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, name: name, perm: perm, mode: mode, extension: extension}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rcreate writes an Rcreate message.
+func (e *Encoder) Rcreate(tag plan9.Tag, qid plan9.QID, iounit uint32) error {
+	m := CreateResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid, iounit: iounit}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tread writes a Tread message.
+func (e *Encoder) Tread(tag plan9.Tag, fid plan9.FID, offset uint64, count uint32) error {
+	m := ReadReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, offset: offset, count: count}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rread writes an Rread message.
+func (e *Encoder) Rread(tag plan9.Tag, data []byte) error {
+	m := ReadResp{header: Header{tag: tag, dialect: e.dialect}, data: data}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Twrite writes a Twrite message.
+func (e *Encoder) Twrite(tag plan9.Tag, fid plan9.FID, offset uint64, data []byte) error {
+	m := WriteReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, offset: offset, data: data}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rwrite writes an Rwrite message.
+func (e *Encoder) Rwrite(tag plan9.Tag, count uint32) error {
+	m := WriteResp{header: Header{tag: tag, dialect: e.dialect}, count: count}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tclunk writes a Tclunk message.
+func (e *Encoder) Tclunk(tag plan9.Tag, fid plan9.FID) error {
+	m := ClunkReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rclunk writes an Rclunk message.
+func (e *Encoder) Rclunk(tag plan9.Tag) error {
+	m := ClunkResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tremove writes a Tremove message.
+func (e *Encoder) Tremove(tag plan9.Tag, fid plan9.FID) error {
+	m := RemoveReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rremove writes an Rremove message.
+func (e *Encoder) Rremove(tag plan9.Tag) error {
+	m := RemoveResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tstat writes a Tstat message.
+func (e *Encoder) Tstat(tag plan9.Tag, fid plan9.FID) error {
+	m := StatReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rstat writes an Rstat message.
+func (e *Encoder) Rstat(tag plan9.Tag, stat *plan9.Dir) error {
+	m := StatResp{header: Header{tag: tag, dialect: e.dialect}, stat: stat}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Twstat writes a Twstat message.
+func (e *Encoder) Twstat(tag plan9.Tag, fid plan9.FID, stat *plan9.Dir) error {
+	m := WstatReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, stat: stat}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rwstat writes an Rwstat message.
+func (e *Encoder) Rwstat(tag plan9.Tag) error {
+	m := WstatResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rlerror writes an Rlerror message.
+func (e *Encoder) Rlerror(tag plan9.Tag, ecode uint32) error {
+	m := LerrorResp{header: Header{tag: tag, dialect: e.dialect}, ecode: ecode}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tstatfs writes a Tstatfs message.
+func (e *Encoder) Tstatfs(tag plan9.Tag, fid plan9.FID) error {
+	m := StatfsReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rstatfs writes an Rstatfs message.
+func (e *Encoder) Rstatfs(tag plan9.Tag, fstype uint32, bsize uint32, blocks uint64, bfree uint64, bavail uint64, files uint64, ffree uint64, fsid uint64, namelen uint32) error {
+	m := StatfsResp{header: Header{tag: tag, dialect: e.dialect}, fstype: fstype, bsize: bsize, blocks: blocks, bfree: bfree, bavail: bavail, files: files, ffree: ffree, fsid: fsid, namelen: namelen}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tlopen writes a Tlopen message.
+func (e *Encoder) Tlopen(tag plan9.Tag, fid plan9.FID, flags uint32) error {
+	m := LopenReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, flags: flags}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rlopen writes an Rlopen message.
+func (e *Encoder) Rlopen(tag plan9.Tag, qid plan9.QID, iounit uint32) error {
+	m := LopenResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid, iounit: iounit}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tlcreate writes a Tlcreate message.
+func (e *Encoder) Tlcreate(tag plan9.Tag, fid plan9.FID, name string, flags uint32, mode uint32, gid uint32) error {
+	m := LcreateReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, name: name, flags: flags, mode: mode, gid: gid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rlcreate writes an Rlcreate message.
+func (e *Encoder) Rlcreate(tag plan9.Tag, qid plan9.QID, iounit uint32) error {
+	m := LcreateResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid, iounit: iounit}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tsymlink writes a Tsymlink message.
+func (e *Encoder) Tsymlink(tag plan9.Tag, fid plan9.FID, name string, symtgt string, gid uint32) error {
+	m := SymlinkReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, name: name, symtgt: symtgt, gid: gid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rsymlink writes an Rsymlink message.
+func (e *Encoder) Rsymlink(tag plan9.Tag, qid plan9.QID) error {
+	m := SymlinkResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tmknod writes a Tmknod message.
+func (e *Encoder) Tmknod(tag plan9.Tag, dfid plan9.FID, name string, mode uint32, major uint32, minor uint32, gid uint32) error {
+	m := MknodReq{header: Header{tag: tag, dialect: e.dialect}, dfid: dfid, name: name, mode: mode, major: major, minor: minor, gid: gid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rmknod writes an Rmknod message.
+func (e *Encoder) Rmknod(tag plan9.Tag, qid plan9.QID) error {
+	m := MknodResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Trename writes a Trename message.
+func (e *Encoder) Trename(tag plan9.Tag, fid plan9.FID, dfid plan9.FID, name string) error {
+	m := RenameReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, dfid: dfid, name: name}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rrename writes an Rrename message.
+func (e *Encoder) Rrename(tag plan9.Tag) error {
+	m := RenameResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Treadlink writes a Treadlink message.
+func (e *Encoder) Treadlink(tag plan9.Tag, fid plan9.FID) error {
+	m := ReadlinkReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rreadlink writes an Rreadlink message.
+func (e *Encoder) Rreadlink(tag plan9.Tag, target string) error {
+	m := ReadlinkResp{header: Header{tag: tag, dialect: e.dialect}, target: target}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tgetattr writes a Tgetattr message.
+func (e *Encoder) Tgetattr(tag plan9.Tag, fid plan9.FID, requestMask uint64) error {
+	m := GetattrReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, requestMask: requestMask}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rgetattr writes an Rgetattr message.
+func (e *Encoder) Rgetattr(tag plan9.Tag, valid uint64, qid plan9.QID, mode uint32, uid uint32, gid uint32, nlink uint64, rdev uint64, length uint64, blksize uint64, blocks uint64, atimeSec uint64, atimeNsec uint64, mtimeSec uint64, mtimeNsec uint64, ctimeSec uint64, ctimeNsec uint64, btimeSec uint64, btimeNsec uint64, gen uint64, dataVersion uint64) error {
+	m := GetattrResp{header: Header{tag: tag, dialect: e.dialect}, valid: valid, qid: qid, mode: mode, uid: uid, gid: gid, nlink: nlink, rdev: rdev, length: length, blksize: blksize, blocks: blocks, atimeSec: atimeSec, atimeNsec: atimeNsec, mtimeSec: mtimeSec, mtimeNsec: mtimeNsec, ctimeSec: ctimeSec, ctimeNsec: ctimeNsec, btimeSec: btimeSec, btimeNsec: btimeNsec, gen: gen, dataVersion: dataVersion}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tsetattr writes a Tsetattr message.
+func (e *Encoder) Tsetattr(tag plan9.Tag, fid plan9.FID, valid uint32, mode uint32, uid uint32, gid uint32, length uint64, atimeSec uint64, atimeNsec uint64, mtimeSec uint64, mtimeNsec uint64) error {
+	m := SetattrReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, valid: valid, mode: mode, uid: uid, gid: gid, length: length, atimeSec: atimeSec, atimeNsec: atimeNsec, mtimeSec: mtimeSec, mtimeNsec: mtimeNsec}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rsetattr writes an Rsetattr message.
+func (e *Encoder) Rsetattr(tag plan9.Tag) error {
+	m := SetattrResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Txattrwalk writes a Txattrwalk message.
+func (e *Encoder) Txattrwalk(tag plan9.Tag, fid plan9.FID, newfid plan9.FID, name string) error {
+	m := XattrwalkReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, newfid: newfid, name: name}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rxattrwalk writes an Rxattrwalk message.
+func (e *Encoder) Rxattrwalk(tag plan9.Tag, attrsize uint64) error {
+	m := XattrwalkResp{header: Header{tag: tag, dialect: e.dialect}, attrsize: attrsize}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Txattrcreate writes a Txattrcreate message.
+func (e *Encoder) Txattrcreate(tag plan9.Tag, fid plan9.FID, name string, attrSize uint64, flags uint32) error {
+	m := XattrcreateReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, name: name, attrSize: attrSize, flags: flags}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rxattrcreate writes an Rxattrcreate message.
+func (e *Encoder) Rxattrcreate(tag plan9.Tag) error {
+	m := XattrcreateResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Treaddir writes a Treaddir message.
+func (e *Encoder) Treaddir(tag plan9.Tag, fid plan9.FID, offset uint64, count uint32) error {
+	m := ReaddirReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, offset: offset, count: count}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rreaddir writes an Rreaddir message.
+func (e *Encoder) Rreaddir(tag plan9.Tag, data []byte) error {
+	m := ReaddirResp{header: Header{tag: tag, dialect: e.dialect}, data: data}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tfsync writes a Tfsync message.
+func (e *Encoder) Tfsync(tag plan9.Tag, fid plan9.FID, datasync uint32) error {
+	m := FsyncReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, datasync: datasync}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rfsync writes an Rfsync message.
+func (e *Encoder) Rfsync(tag plan9.Tag) error {
+	m := FsyncResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tlock writes a Tlock message.
+func (e *Encoder) Tlock(tag plan9.Tag, fid plan9.FID, locktype uint8, flags uint32, start uint64, length uint64, procID uint32, clientID string) error {
+	m := LockReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, locktype: locktype, flags: flags, start: start, length: length, procID: procID, clientID: clientID}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rlock writes an Rlock message.
+func (e *Encoder) Rlock(tag plan9.Tag, status uint8) error {
+	m := LockResp{header: Header{tag: tag, dialect: e.dialect}, status: status}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tgetlock writes a Tgetlock message.
+func (e *Encoder) Tgetlock(tag plan9.Tag, fid plan9.FID, locktype uint8, start uint64, length uint64, procID uint32, clientID string) error {
+	m := GetlockReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, locktype: locktype, start: start, length: length, procID: procID, clientID: clientID}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rgetlock writes an Rgetlock message.
+func (e *Encoder) Rgetlock(tag plan9.Tag, locktype uint8, start uint64, length uint64, procID uint32, clientID string) error {
+	m := GetlockResp{header: Header{tag: tag, dialect: e.dialect}, locktype: locktype, start: start, length: length, procID: procID, clientID: clientID}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tlink writes a Tlink message.
+func (e *Encoder) Tlink(tag plan9.Tag, dfid plan9.FID, fid plan9.FID, name string) error {
+	m := LinkReq{header: Header{tag: tag, dialect: e.dialect}, dfid: dfid, fid: fid, name: name}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rlink writes an Rlink message.
+func (e *Encoder) Rlink(tag plan9.Tag) error {
+	m := LinkResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tmkdir writes a Tmkdir message.
+func (e *Encoder) Tmkdir(tag plan9.Tag, dfid plan9.FID, name string, mode uint32, gid uint32) error {
+	m := MkdirReq{header: Header{tag: tag, dialect: e.dialect}, dfid: dfid, name: name, mode: mode, gid: gid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rmkdir writes an Rmkdir message.
+func (e *Encoder) Rmkdir(tag plan9.Tag, qid plan9.QID) error {
+	m := MkdirResp{header: Header{tag: tag, dialect: e.dialect}, qid: qid}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Trenameat writes a Trenameat message.
+func (e *Encoder) Trenameat(tag plan9.Tag, olddirfid plan9.FID, oldname string, newdirfid plan9.FID, newname string) error {
+	m := RenameatReq{header: Header{tag: tag, dialect: e.dialect}, olddirfid: olddirfid, oldname: oldname, newdirfid: newdirfid, newname: newname}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rrenameat writes an Rrenameat message.
+func (e *Encoder) Rrenameat(tag plan9.Tag) error {
+	m := RenameatResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tunlinkat writes a Tunlinkat message.
+func (e *Encoder) Tunlinkat(tag plan9.Tag, dirfid plan9.FID, name string, flags uint32) error {
+	m := UnlinkatReq{header: Header{tag: tag, dialect: e.dialect}, dirfid: dirfid, name: name, flags: flags}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Runlinkat writes an Runlinkat message.
+func (e *Encoder) Runlinkat(tag plan9.Tag) error {
+	m := UnlinkatResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tsession writes a Tsession message.
+func (e *Encoder) Tsession(tag plan9.Tag, key uint64) error {
+	m := SessionReq{header: Header{tag: tag, dialect: e.dialect}, key: key}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rsession writes an Rsession message.
+func (e *Encoder) Rsession(tag plan9.Tag) error {
+	m := SessionResp{header: Header{tag: tag, dialect: e.dialect}}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tsread writes a Tsread message.
+func (e *Encoder) Tsread(tag plan9.Tag, fid plan9.FID, wname []string) error {
+	m := SreadReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, wname: wname}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rsread writes an Rsread message.
+func (e *Encoder) Rsread(tag plan9.Tag, data []byte) error {
+	m := SreadResp{header: Header{tag: tag, dialect: e.dialect}, data: data}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Tswrite writes a Tswrite message.
+func (e *Encoder) Tswrite(tag plan9.Tag, fid plan9.FID, wname []string, data []byte) error {
+	m := SwriteReq{header: Header{tag: tag, dialect: e.dialect}, fid: fid, wname: wname, data: data}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
+}
+
+// Rswrite writes an Rswrite message.
+func (e *Encoder) Rswrite(tag plan9.Tag, count uint32) error {
+	m := SwriteResp{header: Header{tag: tag, dialect: e.dialect}, count: count}
+	b, err := m.appendBinary(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	return e.write()
 }
@@ -0,0 +1,212 @@
+package plan9
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+)
+
+var (
+	testQID = plan9.QID{Path: 0x0102030405060708, Vers: 3, Type: 0x80}
+	testDir = plan9.Dir{
+		Type:   'M',
+		Dev:    1,
+		QID:    testQID,
+		Mode:   0x800001ed,
+		Atime:  1588888888,
+		Mtime:  1577777777,
+		Length: 0,
+		Name:   "glenda",
+		UID:    "glenda",
+		GID:    "glenda",
+		Muid:   "glenda",
+	}
+)
+
+// testMessages holds one message of every type, with the header size filled in.
+var testMessages = []struct {
+	name string
+	msg  Message
+}{
+	{"Tversion", &VersionReq{header: Header{size: 19, mtype: plan9.Tversion, tag: plan9.NoTag}, msize: 8192, version: plan9.DefaultVersion}},
+	{"Rversion", &VersionResp{header: Header{size: 19, mtype: plan9.Rversion, tag: plan9.NoTag}, msize: 8192, version: plan9.DefaultVersion}},
+	{"Tauth", &AuthReq{header: Header{size: 21, mtype: plan9.Tauth, tag: 1}, afid: 2, uname: "glenda"}},
+	{"Rauth", &AuthResp{header: Header{size: 20, mtype: plan9.Rauth, tag: 1}, aqid: testQID}},
+	{"Rerror", &ErrorResp{header: Header{size: 28, mtype: plan9.Rerror, tag: 1}, ename: "file does not exist"}},
+	{"Tflush", &FlushReq{header: Header{size: 9, mtype: plan9.Tflush, tag: 2}, oldtag: 1}},
+	{"Rflush", &FlushResp{header: Header{size: 7, mtype: plan9.Rflush, tag: 2}}},
+	{"Tattach", &AttachReq{header: Header{size: 25, mtype: plan9.Tattach, tag: 1}, fid: 1, afid: plan9.NoFID, uname: "glenda"}},
+	{"Rattach", &AttachResp{header: Header{size: 20, mtype: plan9.Rattach, tag: 1}, qid: testQID}},
+	{"Twalk", &WalkReq{header: Header{size: 30, mtype: plan9.Twalk, tag: 1}, fid: 1, newfid: 2, wname: []string{"usr", "glenda"}}},
+	{"Rwalk", &WalkResp{header: Header{size: 35, mtype: plan9.Rwalk, tag: 1}, wqid: []plan9.QID{testQID, testQID}}},
+	{"Topen", &OpenReq{header: Header{size: 12, mtype: plan9.Topen, tag: 1}, fid: 2}},
+	{"Ropen", &OpenResp{header: Header{size: 24, mtype: plan9.Ropen, tag: 1}, qid: testQID, iounit: 8168}},
+	{"Tcreate", &CreateReq{header: Header{size: 21, mtype: plan9.Tcreate, tag: 1}, fid: 2, name: "lib", perm: 0x800001ed}},
+	{"Rcreate", &CreateResp{header: Header{size: 24, mtype: plan9.Rcreate, tag: 1}, qid: testQID, iounit: 8168}},
+	{"Tread", &ReadReq{header: Header{size: 23, mtype: plan9.Tread, tag: 1}, fid: 2, count: 8168}},
+	{"Rread", &ReadResp{header: Header{size: 16, mtype: plan9.Rread, tag: 1}, data: []byte("hello")}},
+	{"Twrite", &WriteReq{header: Header{size: 28, mtype: plan9.Twrite, tag: 1}, fid: 2, offset: 5, data: []byte("world")}},
+	{"Rwrite", &WriteResp{header: Header{size: 11, mtype: plan9.Rwrite, tag: 1}, count: 5}},
+	{"Tclunk", &ClunkReq{header: Header{size: 11, mtype: plan9.Tclunk, tag: 1}, fid: 2}},
+	{"Rclunk", &ClunkResp{header: Header{size: 7, mtype: plan9.Rclunk, tag: 1}}},
+	{"Tremove", &RemoveReq{header: Header{size: 11, mtype: plan9.Tremove, tag: 1}, fid: 2}},
+	{"Rremove", &RemoveResp{header: Header{size: 7, mtype: plan9.Rremove, tag: 1}}},
+	{"Tstat", &StatReq{header: Header{size: 11, mtype: plan9.Tstat, tag: 1}, fid: 1}},
+	{"Rstat", &StatResp{header: Header{size: 82, mtype: plan9.Rstat, tag: 1}, stat: &testDir}},
+	{"Twstat", &WstatReq{header: Header{size: 86, mtype: plan9.Twstat, tag: 1}, fid: 1, stat: &testDir}},
+	{"Rwstat", &WstatResp{header: Header{size: 7, mtype: plan9.Rwstat, tag: 1}}},
+	{"Rlerror", &LerrorResp{header: Header{size: 11, mtype: plan9.Rlerror, tag: 1}, ecode: 2}},
+	{"Tstatfs", &StatfsReq{header: Header{size: 11, mtype: plan9.Tstatfs, tag: 1}, fid: 1}},
+	{"Rstatfs", &StatfsResp{header: Header{size: 67, mtype: plan9.Rstatfs, tag: 1}, fstype: 0x01021997, bsize: 4096, blocks: 1 << 20, bfree: 1 << 19, bavail: 1 << 19, files: 1 << 16, ffree: 1 << 15, fsid: 1, namelen: 255}},
+	{"Tlopen", &LopenReq{header: Header{size: 15, mtype: plan9.Tlopen, tag: 1}, fid: 2, flags: 0x8000}},
+	{"Rlopen", &LopenResp{header: Header{size: 24, mtype: plan9.Rlopen, tag: 1}, qid: testQID, iounit: 8168}},
+	{"Tlcreate", &LcreateReq{header: Header{size: 28, mtype: plan9.Tlcreate, tag: 1}, fid: 2, name: "lib", flags: 0x8041, mode: 0644, gid: 1000}},
+	{"Tsymlink", &SymlinkReq{header: Header{size: 30, mtype: plan9.Tsymlink, tag: 1}, fid: 2, name: "lib", symtgt: "/usr/lib", gid: 1000}},
+	{"Tgetattr", &GetattrReq{header: Header{size: 19, mtype: plan9.Tgetattr, tag: 1}, fid: 2, requestMask: 0x7ff}},
+	{"Rgetattr", &GetattrResp{header: Header{size: 160, mtype: plan9.Rgetattr, tag: 1}, valid: 0x7ff, qid: testQID, mode: 040755, uid: 1000, gid: 1000, nlink: 2, length: 4096, blksize: 4096, blocks: 8, mtimeSec: 1577777777, atimeSec: 1588888888}},
+	{"Treaddir", &ReaddirReq{header: Header{size: 23, mtype: plan9.Treaddir, tag: 1}, fid: 2, offset: 0, count: 8168}},
+	{"Rreaddir", &ReaddirResp{header: Header{size: 16, mtype: plan9.Rreaddir, tag: 1}, data: []byte("hello")}},
+	{"Tlock", &LockReq{header: Header{size: 44, mtype: plan9.Tlock, tag: 1}, fid: 2, locktype: 1, start: 0, length: 100, procID: 42, clientID: "glenda"}},
+	{"Rlock", &LockResp{header: Header{size: 8, mtype: plan9.Rlock, tag: 1}, status: 0}},
+	{"Trenameat", &RenameatReq{header: Header{size: 21, mtype: plan9.Trenameat, tag: 1}, olddirfid: 1, oldname: "a", newdirfid: 2, newname: "b"}},
+	{"Runlinkat", &UnlinkatResp{header: Header{size: 7, mtype: plan9.Runlinkat, tag: 1}}},
+	{"Tsession", &SessionReq{header: Header{size: 15, mtype: plan9.Tsession, tag: 1}, key: 0xdeadbeef}},
+	{"Rsession", &SessionResp{header: Header{size: 7, mtype: plan9.Rsession, tag: 1}}},
+	{"Tsread", &SreadReq{header: Header{size: 26, mtype: plan9.Tsread, tag: 1}, fid: 1, wname: []string{"usr", "glenda"}}},
+	{"Rsread", &SreadResp{header: Header{size: 16, mtype: plan9.Rsread, tag: 1}, data: []byte("hello")}},
+	{"Tswrite", &SwriteReq{header: Header{size: 27, mtype: plan9.Tswrite, tag: 1}, fid: 1, wname: []string{"tmp"}, data: []byte("world")}},
+	{"Rswrite", &SwriteResp{header: Header{size: 11, mtype: plan9.Rswrite, tag: 1}, count: 5}},
+}
+
+func TestMarshalBinary(t *testing.T) {
+	for _, tt := range testMessages {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.msg.MarshalBinary()
+			assert.NilError(t, err)
+			msg, err := Decode(bytes.NewReader(b))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, msg, tt.msg)
+		})
+	}
+}
+
+func TestDialectU(t *testing.T) {
+	u := plan9.Dialect9P2000u
+	dir := testDir
+	dir.Extension, dir.NUID, dir.NGID, dir.NMUID = "/tmp/glenda", 1000, 1000, 1001
+	tests := []struct {
+		name string
+		msg  Message
+		// stat records are sized, so 9P2000 skips the extra fields
+		trailing bool
+	}{
+		{"Tauth", &AuthReq{header: Header{size: 25, mtype: plan9.Tauth, tag: 1, dialect: u}, afid: 2, uname: "glenda", nUname: 1000}, true},
+		{"Rerror", &ErrorResp{header: Header{size: 32, mtype: plan9.Rerror, tag: 1, dialect: u}, ename: "file does not exist", errno: 2}, true},
+		{"Tattach", &AttachReq{header: Header{size: 29, mtype: plan9.Tattach, tag: 1, dialect: u}, fid: 1, afid: plan9.NoFID, uname: "glenda", nUname: 1000}, true},
+		{"Tcreate", &CreateReq{header: Header{size: 27, mtype: plan9.Tcreate, tag: 1, dialect: u}, fid: 2, name: "lib", perm: 0x02000000, extension: "/tmp"}, true},
+		{"Rstat", &StatResp{header: Header{size: 107, mtype: plan9.Rstat, tag: 1, dialect: u}, stat: &dir}, false},
+		{"Twstat", &WstatReq{header: Header{size: 111, mtype: plan9.Twstat, tag: 1, dialect: u}, fid: 1, stat: &dir}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.msg.MarshalBinary()
+			assert.NilError(t, err)
+			assert.Equal(t, tt.msg.Size(), plan9.Size(len(b)))
+			dec := NewDecoder(bytes.NewReader(b))
+			dec.SetDialect(u)
+			msg, err := dec.Decode()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, msg, tt.msg)
+
+			_, err = Decode(bytes.NewReader(b))
+			if (err != nil) != tt.trailing {
+				t.Fatalf("Decode in 9P2000 error = %v, want trailing data %v", err, tt.trailing)
+			}
+		})
+	}
+}
+
+func TestMarshalBinaryHardcoded(t *testing.T) {
+	msg := &VersionReq{header: Header{tag: 0xaa55}, msize: 8192, version: plan9.DefaultVersion}
+	b, err := msg.MarshalBinary()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, b, []byte{19, 0, 0, 0, 100, 0x55, 0xaa, 0, 32, 0, 0, 6, 0, 57, 80, 50, 48, 48, 48})
+}
+
+func TestAccessors(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	enc := NewEncoder(b)
+	enc.Twalk(7, 1, 2, []string{"usr", "glenda"})
+	enc.Rstat(8, &testDir)
+	enc.Flush()
+	dec := NewDecoder(b)
+
+	msg, err := dec.Decode()
+	assert.NilError(t, err)
+	walk := msg.(*WalkReq)
+	assert.Equal(t, walk.Header().Size(), plan9.Size(30))
+	assert.Equal(t, walk.Header().Type(), plan9.Twalk)
+	assert.Equal(t, walk.Header().Tag(), plan9.Tag(7))
+	assert.Equal(t, walk.Fid(), plan9.FID(1))
+	assert.Equal(t, walk.Newfid(), plan9.FID(2))
+	assert.DeepEqual(t, walk.Wname(), []string{"usr", "glenda"})
+
+	msg, err = dec.Decode()
+	assert.NilError(t, err)
+	assert.Equal(t, msg.Header().Tag(), plan9.Tag(8))
+	assert.DeepEqual(t, msg.(*StatResp).Stat(), &testDir)
+}
+
+func TestUnmarshalBinaryTruncated(t *testing.T) {
+	for _, tt := range testMessages {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.msg.MarshalBinary()
+			assert.NilError(t, err)
+			for n := 7; n < len(b); n++ {
+				msg := newMessage(tt.msg.Header())
+				err := msg.UnmarshalBinary(b[7:n])
+				derr, ok := err.(*DecodeError)
+				if !ok {
+					t.Fatalf("UnmarshalBinary(b[7:%d]) = %v, want *DecodeError", n, err)
+				}
+				assert.Equal(t, derr.Type, tt.msg.Header().Type())
+				if derr.Offset < 7 || derr.Offset > n {
+					t.Fatalf("UnmarshalBinary(b[7:%d]) offset = %d", n, derr.Offset)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	b, _ := (&WalkReq{header: Header{tag: 1}, fid: 1, newfid: 2, wname: []string{"usr", "glenda"}}).MarshalBinary()
+	b[18] = 0xff // length of "usr"
+	_, err := Decode(bytes.NewReader(b))
+	assert.DeepEqual(t, err, &DecodeError{Type: plan9.Twalk, Offset: 17, Field: "wname", Err: ErrStringMalformed})
+}
+
+func TestDecodeGarbage(t *testing.T) {
+	rand.Seed(1)
+	for i := 0; i < 100000; i++ {
+		b := make([]byte, 7+rand.Intn(64))
+		rand.Read(b)
+		b[4] = byte(rand.Intn(int(plan9.Rswrite) + 1))
+		encoder.PutUint32(b, uint32(len(b)))
+		Decode(bytes.NewReader(b))
+	}
+}
+
+func TestSize(t *testing.T) {
+	for _, tt := range testMessages {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.msg.MarshalBinary()
+			assert.NilError(t, err)
+			assert.Equal(t, tt.msg.Size(), plan9.Size(len(b)))
+			assert.Equal(t, tt.msg.Size(), tt.msg.Header().Size())
+		})
+	}
+	var _ plan9.Message = Message(nil)
+}
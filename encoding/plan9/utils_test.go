@@ -29,6 +29,8 @@ func tag() plan9.Tag {
 func dir() plan9.Dir {
 	d := plan9.Dir{}
 	fuzzer.Fuzz(&d)
+	// styxproto speaks 9P2000 only
+	d.Extension, d.NUID, d.NGID, d.NMUID = "", 0, 0, 0
 	return d
 }
 func stat() (plan9.Dir, styxproto.Stat) {
@@ -0,0 +1,36 @@
+package plan9
+
+import (
+	"fmt"
+
+	"plan9.io"
+)
+
+// Version performs the client side of version(5): it sends a Tversion
+// offering msize and version, waits for the Rversion and switches enc and
+// dec to the agreed session. It must be the first message on a connection.
+func Version(enc *Encoder, dec *Decoder, msize uint32, version string) (plan9.Session, error) {
+	if err := enc.Tversion(plan9.NoTag, msize, version); err != nil {
+		return plan9.Session{}, err
+	}
+	if err := enc.Flush(); err != nil {
+		return plan9.Session{}, err
+	}
+	msg, err := dec.Decode()
+	if err != nil {
+		return plan9.Session{}, err
+	}
+	switch r := msg.(type) {
+	case *VersionResp:
+		s, err := plan9.Accept(msize, version, r.msize, r.version)
+		if err != nil {
+			return plan9.Session{}, err
+		}
+		enc.SetSession(s)
+		dec.SetSession(s)
+		return s, nil
+	case *ErrorResp:
+		return plan9.Session{}, fmt.Errorf("9p: Tversion: %s", r.ename)
+	}
+	return plan9.Session{}, fmt.Errorf("9p: unexpected %v in reply to Tversion", msg.Header().Type())
+}
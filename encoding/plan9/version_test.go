@@ -0,0 +1,68 @@
+package plan9
+
+import (
+	"net"
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+)
+
+func TestVersion(t *testing.T) {
+	c, s := net.Pipe()
+	defer c.Close()
+	go func() {
+		defer s.Close()
+		dec, enc := NewDecoder(s), NewEncoder(s)
+		msg, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		v := msg.(*VersionReq)
+		session, _ := plan9.Negotiate(v.Msize(), v.Version(), 8192, plan9.Dialect9P2000, plan9.Dialect9P2000u)
+		enc.Rversion(v.Header().Tag(), session.Msize, session.Version)
+		enc.Flush()
+		dec.SetSession(session)
+		enc.SetSession(session)
+		if msg, err = dec.Decode(); err != nil {
+			return
+		}
+		a := msg.(*AttachReq)
+		enc.Rerror(a.Header().Tag(), "nuname", a.NUname())
+		enc.Flush()
+	}()
+
+	enc, dec := NewEncoder(c), NewDecoder(c)
+	session, err := Version(enc, dec, plan9.MSize, plan9.VersionU)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, session, plan9.Session{Msize: 8192, Version: plan9.VersionU, Dialect: plan9.Dialect9P2000u})
+
+	assert.NilError(t, enc.Tattach(1, 1, plan9.NoFID, "glenda", "", 1000))
+	assert.NilError(t, enc.Flush())
+	msg, err := dec.Decode()
+	assert.NilError(t, err)
+	assert.Equal(t, msg.(*ErrorResp).Errno(), uint32(1000))
+	assert.Equal(t, enc.Rread(1, make([]byte, 8192)), ErrMsgTooLarge)
+}
+
+func TestVersionUnknown(t *testing.T) {
+	c, s := net.Pipe()
+	defer c.Close()
+	go func() {
+		defer s.Close()
+		dec, enc := NewDecoder(s), NewEncoder(s)
+		msg, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		v := msg.(*VersionReq)
+		session, _ := plan9.Negotiate(v.Msize(), v.Version(), 8192)
+		enc.Rversion(v.Header().Tag(), session.Msize, session.Version)
+		enc.Flush()
+	}()
+
+	_, err := Version(NewEncoder(c), NewDecoder(c), plan9.MSize, "9P3000")
+	if err == nil {
+		t.Fatal("Version succeeded with an unknown version")
+	}
+}
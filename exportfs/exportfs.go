@@ -0,0 +1,457 @@
+// Package exportfs serves a directory tree over 9P, like Plan 9's
+// exportfs(4).
+package exportfs
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"plan9.io"
+	"plan9.io/server"
+)
+
+var (
+	errNoSeek    = errors.New("file cannot be read at an offset")
+	errBadLength = errors.New("bad length in wstat")
+)
+
+// FS is a server.Handler serving a file tree. QID paths are the inode
+// numbers of the files where the system has them, and QID versions
+// change with their modification times.
+type FS struct {
+	fsys fs.FS  // the tree served read-only
+	root string // the directory served read-write, or ""
+}
+
+// New returns an FS serving the directory dir read-write.
+func New(dir string) *FS {
+	return &FS{root: dir}
+}
+
+// NewFS returns an FS serving fsys read-only.
+func NewFS(fsys fs.FS) *FS {
+	return &FS{fsys: fsys}
+}
+
+// file is the Aux of a fid.
+type file struct {
+	mu     sync.Mutex // guards the fields below
+	name   string     // slash-separated path from the root, "." for the root
+	f      fs.File    // the open file, or nil
+	rclose bool       // remove on clunk
+	dirs   server.DirList
+}
+
+func aux(fid *server.Fid) *file { return fid.Aux.(*file) }
+
+// path returns the system path of name.
+func (x *FS) path(name string) string {
+	return filepath.Join(x.root, filepath.FromSlash(name))
+}
+
+// resolve returns the system path of name with its symbolic links
+// followed, or server.ErrPerm if they lead out of the served directory.
+func (x *FS) resolve(name string) (string, error) {
+	root, err := filepath.EvalSymlinks(x.root)
+	if err != nil {
+		return "", fserr(err)
+	}
+	p, err := filepath.EvalSymlinks(x.path(name))
+	if err != nil {
+		return "", fserr(err)
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", server.ErrPerm
+	}
+	return p, nil
+}
+
+func (x *FS) stat(name string) (fs.FileInfo, error) {
+	if x.root != "" {
+		p, err := x.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		return os.Stat(p)
+	}
+	return fs.Stat(x.fsys, name)
+}
+
+// dir converts the FileInfo of name.
+func (x *FS) dir(name string, fi fs.FileInfo) *plan9.Dir {
+	d := &plan9.Dir{
+		Name:  fi.Name(),
+		Mode:  plan9.FromFileMode(fi.Mode()),
+		Atime: uint32(fi.ModTime().Unix()),
+		Mtime: uint32(fi.ModTime().Unix()),
+		UID:   "none",
+		GID:   "none",
+	}
+	if name == "." {
+		d.Name = "/"
+	}
+	if !fi.IsDir() {
+		d.Length = uint64(fi.Size())
+	}
+	d.QID.Path = sysStat(fi, d)
+	if d.QID.Path == 0 {
+		h := fnv.New64a()
+		io.WriteString(h, name)
+		d.QID.Path = h.Sum64()
+	}
+	d.QID.Type = d.Mode.QIDType()
+	ns := fi.ModTime().UnixNano()
+	d.QID.Vers = uint32(ns ^ ns>>32)
+	d.Muid = d.UID
+	return d
+}
+
+func (x *FS) qid(name string) (plan9.QID, error) {
+	fi, err := x.stat(name)
+	if err != nil {
+		return plan9.QID{}, fserr(err)
+	}
+	return x.dir(name, fi).QID, nil
+}
+
+// fserr strips the system path from err.
+func fserr(err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return pe.Err
+	}
+	var le *os.LinkError
+	if errors.As(err, &le) {
+		return le.Err
+	}
+	return err
+}
+
+func (x *FS) Attach(ctx context.Context, fid, afid *server.Fid, uname, aname string) (plan9.QID, error) {
+	qid, err := x.qid(".")
+	if err != nil {
+		return plan9.QID{}, err
+	}
+	fid.Aux = &file{name: "."}
+	return qid, nil
+}
+
+func (x *FS) Walk(ctx context.Context, fid, newfid *server.Fid, names []string) ([]plan9.QID, error) {
+	f := aux(fid)
+	f.mu.Lock()
+	name := f.name
+	f.mu.Unlock()
+	var qids []plan9.QID
+	for _, n := range names {
+		if n != ".." && server.CheckName(n) != nil {
+			if len(qids) == 0 {
+				return nil, server.ErrBadName
+			}
+			break
+		}
+		next := path.Join(name, n)
+		if next == ".." {
+			next = "." // .. of the root is the root
+		}
+		qid, err := x.qid(next)
+		if err != nil {
+			if len(qids) == 0 {
+				return nil, err
+			}
+			break
+		}
+		name = next
+		qids = append(qids, qid)
+	}
+	switch {
+	case len(qids) < len(names):
+	case newfid == fid:
+		// other requests on fid may be using f
+		f.mu.Lock()
+		f.name = name
+		f.mu.Unlock()
+	default:
+		newfid.Aux = &file{name: name}
+	}
+	return qids, nil
+}
+
+// flags returns the os.OpenFile flags of a Topen mode.
+func flags(mode uint8) int {
+	var flag int
+	switch mode & 3 {
+	case plan9.OREAD, plan9.OEXEC:
+		flag = os.O_RDONLY
+	case plan9.OWRITE:
+		flag = os.O_WRONLY
+	case plan9.ORDWR:
+		flag = os.O_RDWR
+	}
+	if mode&plan9.OTRUNC != 0 {
+		flag |= os.O_TRUNC
+	}
+	return flag
+}
+
+// writes reports whether a Topen mode changes the file.
+func writes(mode uint8) bool {
+	o := mode & 3
+	return o == plan9.OWRITE || o == plan9.ORDWR || mode&(plan9.OTRUNC|plan9.ORCLOSE) != 0
+}
+
+func (x *FS) Open(ctx context.Context, fid *server.Fid, mode uint8) (plan9.QID, uint32, error) {
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var (
+		of  fs.File
+		err error
+	)
+	switch {
+	case x.root != "":
+		var p string
+		if p, err = x.resolve(f.name); err == nil {
+			of, err = os.OpenFile(p, flags(mode), 0)
+		}
+	case writes(mode):
+		return plan9.QID{}, 0, server.ErrPerm
+	default:
+		of, err = x.fsys.Open(f.name)
+	}
+	if err != nil {
+		return plan9.QID{}, 0, fserr(err)
+	}
+	fi, err := of.Stat()
+	if err != nil {
+		of.Close()
+		return plan9.QID{}, 0, fserr(err)
+	}
+	f.f, f.rclose = of, mode&plan9.ORCLOSE != 0
+	return x.dir(f.name, fi).QID, 0, nil
+}
+
+func (x *FS) Create(ctx context.Context, fid *server.Fid, name string, perm plan9.Perm, mode uint8) (plan9.QID, uint32, error) {
+	if x.root == "" {
+		return plan9.QID{}, 0, server.ErrPerm
+	}
+	if err := server.CheckName(name); err != nil {
+		return plan9.QID{}, 0, err
+	}
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dirp, err := x.resolve(f.name)
+	if err != nil {
+		return plan9.QID{}, 0, err
+	}
+	parent, err := os.Stat(dirp)
+	if err != nil {
+		return plan9.QID{}, 0, fserr(err)
+	}
+	perm = server.CreatePerm(perm, plan9.Perm(parent.Mode().Perm()))
+	child, p := path.Join(f.name, name), filepath.Join(dirp, name)
+	var of *os.File
+	if perm&plan9.DMDIR != 0 {
+		err = os.Mkdir(p, os.FileMode(perm&0777))
+		if err == nil {
+			of, err = os.Open(p)
+		}
+	} else {
+		of, err = os.OpenFile(p, flags(mode)|os.O_CREATE|os.O_EXCL, os.FileMode(perm&0777))
+	}
+	if err != nil {
+		return plan9.QID{}, 0, fserr(err)
+	}
+	fi, err := of.Stat()
+	if err != nil {
+		of.Close()
+		return plan9.QID{}, 0, fserr(err)
+	}
+	f.name, f.f, f.rclose = child, of, mode&plan9.ORCLOSE != 0
+	return x.dir(child, fi).QID, 0, nil
+}
+
+func (x *FS) Read(ctx context.Context, fid *server.Fid, offset uint64, p []byte) (int, error) {
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fid.QID().IsDir() {
+		return f.dirs.Read(offset, p, func() ([]plan9.Dir, error) { return x.list(f) })
+	}
+	switch of := f.f.(type) {
+	case io.ReaderAt:
+		return of.ReadAt(p, int64(offset))
+	case io.ReadSeeker:
+		if _, err := of.Seek(int64(offset), io.SeekStart); err != nil {
+			return 0, fserr(err)
+		}
+		n, err := io.ReadFull(of, p)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return n, err
+	}
+	return 0, errNoSeek
+}
+
+// list returns the entries of the directory f. Those gone since the
+// directory was read are left out.
+func (x *FS) list(f *file) ([]plan9.Dir, error) {
+	var (
+		ents []fs.DirEntry
+		err  error
+	)
+	if x.root != "" {
+		ents, err = os.ReadDir(x.path(f.name))
+	} else {
+		ents, err = fs.ReadDir(x.fsys, f.name)
+	}
+	if err != nil {
+		return nil, fserr(err)
+	}
+	dirs := make([]plan9.Dir, 0, len(ents))
+	for _, e := range ents {
+		name := path.Join(f.name, e.Name())
+		if fi, err := x.stat(name); err == nil {
+			dirs = append(dirs, *x.dir(name, fi))
+		}
+	}
+	return dirs, nil
+}
+
+func (x *FS) Write(ctx context.Context, fid *server.Fid, offset uint64, data []byte) (int, error) {
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	of, ok := f.f.(io.WriterAt)
+	if !ok {
+		return 0, server.ErrPerm
+	}
+	n, err := of.WriteAt(data, int64(offset))
+	return n, fserr(err)
+}
+
+// close closes the open file of f, if any.
+func (f *file) close() {
+	if f.f != nil {
+		f.f.Close()
+		f.f, f.dirs = nil, server.DirList{}
+	}
+}
+
+func (x *FS) Clunk(ctx context.Context, fid *server.Fid) error {
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.close()
+	if f.rclose {
+		f.rclose = false
+		return fserr(os.Remove(x.path(f.name)))
+	}
+	return nil
+}
+
+func (x *FS) Remove(ctx context.Context, fid *server.Fid) error {
+	if x.root == "" {
+		return server.ErrPerm
+	}
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.close()
+	f.rclose = false
+	if f.name == "." {
+		return server.ErrPerm
+	}
+	return fserr(os.Remove(x.path(f.name)))
+}
+
+func (x *FS) Stat(ctx context.Context, fid *server.Fid) (*plan9.Dir, error) {
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fi, err := x.stat(f.name)
+	if err != nil {
+		return nil, fserr(err)
+	}
+	return x.dir(f.name, fi), nil
+}
+
+// Wstat changes the name, permissions, length and modification time of a
+// file. The other fields must be left unchanged. Every field is checked
+// before any is changed.
+func (x *FS) Wstat(ctx context.Context, fid *server.Fid, d *plan9.Dir) error {
+	if x.root == "" {
+		return server.ErrPerm
+	}
+	f := aux(fid)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := x.resolve(f.name)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		return fserr(err)
+	}
+	old := x.dir(f.name, fi)
+	if d.Type != ^uint16(0) || d.Dev != ^uint32(0) || d.UID != "" || d.GID != "" || d.Muid != "" ||
+		d.Atime != ^uint32(0) && d.Atime != old.Atime ||
+		d.Mode != ^plan9.Perm(0) && d.Mode&^0777 != old.Mode&^0777 {
+		return server.ErrPerm
+	}
+	if d.Length != ^uint64(0) && d.Length != old.Length {
+		if fi.IsDir() {
+			return server.ErrIsDir
+		}
+		if d.Length > math.MaxInt64 {
+			return errBadLength
+		}
+	}
+	name := f.name
+	if d.Name != "" && d.Name != old.Name {
+		if f.name == "." || server.CheckName(d.Name) != nil {
+			return server.ErrBadName
+		}
+		name = path.Join(path.Dir(f.name), d.Name)
+		if _, err := os.Lstat(x.path(name)); err == nil {
+			return fs.ErrExist
+		}
+	}
+
+	if d.Mode != ^plan9.Perm(0) {
+		if err := os.Chmod(p, os.FileMode(d.Mode&0777)); err != nil {
+			return fserr(err)
+		}
+	}
+	if d.Length != ^uint64(0) && d.Length != old.Length {
+		if err := os.Truncate(p, int64(d.Length)); err != nil {
+			return fserr(err)
+		}
+	}
+	if d.Mtime != ^uint32(0) {
+		t := time.Unix(int64(d.Mtime), 0)
+		if err := os.Chtimes(p, t, t); err != nil {
+			return fserr(err)
+		}
+	}
+	if name != f.name {
+		if err := os.Rename(x.path(f.name), x.path(name)); err != nil {
+			return fserr(err)
+		}
+		f.name = name
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package exportfs
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	"plan9.io/client"
+	"plan9.io/server"
+)
+
+// testRoot serves h on one end of a pipe and attaches to it from the other.
+func testRoot(t *testing.T, h server.Handler) *client.File {
+	c, s := net.Pipe()
+	go (&server.Server{Handler: h, Msize: 8192}).ServeConn(s)
+	conn, err := client.NewConn(context.Background(), c, plan9.MSize, plan9.DefaultVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	root, err := conn.Root(context.Background(), "glenda", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+var testFiles = map[string]string{
+	"lib/profile":  "bind -a $home/bin/$cputype /bin\n",
+	"lib/plumbing": "include basic\n",
+	"bin/rc/g":     "#!/bin/rc\n",
+	"tmp/empty":    "",
+}
+
+func TestNewFS(t *testing.T) {
+	ctx := context.Background()
+	mfs := fstest.MapFS{}
+	for name, content := range testFiles {
+		mfs[name] = &fstest.MapFile{Data: []byte(content), Mode: 0644}
+	}
+	root := testRoot(t, NewFS(mfs))
+	if err := fstest.TestFS(client.NewFS(root), "lib/profile", "lib/plumbing", "bin/rc/g", "tmp/empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	perm := &client.Error{Ename: server.ErrPerm.Error()}
+	f, err := root.Walk(ctx, "lib", "profile")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, f.Open(ctx, plan9.OWRITE), perm)
+	assert.DeepEqual(t, f.Open(ctx, plan9.OREAD|plan9.ORCLOSE), perm)
+	assert.DeepEqual(t, f.Remove(ctx), perm)
+	f, err = root.Walk(ctx, "tmp")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, f.Create(ctx, "x", 0644, plan9.OWRITE), perm)
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for name, content := range testFiles {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NilError(t, ioutil.WriteFile(p, []byte(content), 0644))
+	}
+	root := testRoot(t, New(dir))
+	if err := fstest.TestFS(client.NewFS(root), "lib/profile", "lib/plumbing", "bin/rc/g", "tmp/empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	// walking out of the tree stays at its root
+	f, err := root.Walk(ctx, "..", "lib", "..", "..")
+	assert.NilError(t, err)
+	assert.Equal(t, f.QID(), root.QID())
+
+	// qids follow the files, their versions the modification times
+	f, err = root.Walk(ctx, "lib", "profile")
+	assert.NilError(t, err)
+	g, err := root.Walk(ctx, "lib", "profile")
+	assert.NilError(t, err)
+	assert.Equal(t, f.QID(), g.QID())
+	h, err := root.Walk(ctx, "lib", "plumbing")
+	assert.NilError(t, err)
+	if f.QID().Path == h.QID().Path {
+		t.Fatalf("two files have the qid path %#x", f.QID().Path)
+	}
+	old := time.Unix(1577777777, 0)
+	assert.NilError(t, os.Chtimes(filepath.Join(dir, "lib", "profile"), old, old))
+	g, err = root.Walk(ctx, "lib", "profile")
+	assert.NilError(t, err)
+	assert.Equal(t, g.QID().Path, f.QID().Path)
+	if g.QID().Vers == f.QID().Vers {
+		t.Fatal("qid version unchanged by a new modification time")
+	}
+	d, err := g.Stat(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Mtime, uint32(old.Unix()))
+	assert.Equal(t, d.Mode, plan9.Perm(0644))
+	assert.NilError(t, d.Check())
+
+	tests := []struct {
+		name string
+		do   func(f *client.File) error
+		want string // the contents afterwards, "gone" if removed
+	}{
+		{"write", func(f *client.File) error {
+			if err := f.Open(ctx, plan9.OWRITE); err != nil {
+				return err
+			}
+			_, err := f.WriteAt([]byte("B"), 0)
+			return err
+		}, "Bind -a $home/bin/$cputype /bin\n"},
+		{"truncate", func(f *client.File) error { return f.Open(ctx, plan9.OREAD|plan9.OTRUNC) }, ""},
+		{"remove on close", func(f *client.File) error { return f.Open(ctx, plan9.OREAD|plan9.ORCLOSE) }, "gone"},
+		{"remove", func(f *client.File) error { return f.Remove(ctx) }, "gone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, "lib", "profile")
+			assert.NilError(t, ioutil.WriteFile(p, []byte(testFiles["lib/profile"]), 0644))
+			f, err := root.Walk(ctx, "lib", "profile")
+			assert.NilError(t, err)
+			assert.NilError(t, tt.do(f))
+			f.Close()
+			b, err := ioutil.ReadFile(p)
+			if os.IsNotExist(err) {
+				b, err = []byte("gone"), nil
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, string(b), tt.want)
+		})
+	}
+
+	// created files take the permissions of their directory
+	assert.NilError(t, os.Chmod(filepath.Join(dir, "tmp"), 0750))
+	f, err = root.Walk(ctx, "tmp")
+	assert.NilError(t, err)
+	assert.NilError(t, f.Create(ctx, "new", 0666, plan9.OWRITE))
+	_, err = f.Write([]byte("new"))
+	assert.NilError(t, err)
+	d, err = f.Stat(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Mode, plan9.Perm(0640))
+	assert.Equal(t, d.Length, uint64(3))
+
+	// wstat renames and changes modes
+	nd := plan9.NullDir().WithName("renamed").WithMode(0600)
+	assert.NilError(t, f.Wstat(ctx, &nd))
+	f.Close()
+	fi, err := os.Stat(filepath.Join(dir, "tmp", "renamed"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode(), os.FileMode(0600))
+
+	// a failed wstat changes nothing
+	f, err = root.Walk(ctx, "tmp", "renamed")
+	assert.NilError(t, err)
+	nd = plan9.NullDir().WithName("empty").WithMode(0644)
+	if f.Wstat(ctx, &nd) == nil {
+		t.Fatal("renamed over an existing file")
+	}
+	f.Close()
+	fi, err = os.Stat(filepath.Join(dir, "tmp", "renamed"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode(), os.FileMode(0600))
+}
+
+func TestNewSymlinks(t *testing.T) {
+	ctx := context.Background()
+	dir, outside := t.TempDir(), t.TempDir()
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "in"), []byte("in"), 0644))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644))
+	assert.NilError(t, os.Symlink("in", filepath.Join(dir, "link")))
+	assert.NilError(t, os.Symlink(outside, filepath.Join(dir, "out")))
+	assert.NilError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(dir, "secret")))
+	root := testRoot(t, New(dir))
+
+	f, err := root.Walk(ctx, "link")
+	assert.NilError(t, err)
+	assert.NilError(t, f.Open(ctx, plan9.OREAD))
+	f.Close()
+
+	// links out of the tree are refused
+	perm := &client.Error{Ename: server.ErrPerm.Error()}
+	for _, names := range [][]string{{"out"}, {"out", "secret"}, {"secret"}} {
+		_, err := root.Walk(ctx, names...)
+		assert.DeepEqual(t, err, perm)
+	}
+}
+
+func TestNewWalkSelf(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, strings.Repeat("a/", 100)), 0755))
+	c, s := net.Pipe()
+	go (&server.Server{Handler: New(dir), Msize: 8192}).ServeConn(s)
+	conn, err := client.NewConn(ctx, c, plan9.MSize, plan9.DefaultVersion)
+	assert.NilError(t, err)
+	defer conn.Close()
+	_, err = conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.NilError(t, err)
+
+	// a fid walked to itself while it is stat'd
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := conn.Walk(ctx, 1, 1, "a")
+			assert.NilError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := conn.Stat(ctx, 1)
+			assert.NilError(t, err)
+		}()
+	}
+	wg.Wait()
+	d, err := conn.Stat(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Name, "a")
+}
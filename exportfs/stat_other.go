@@ -0,0 +1,13 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package exportfs
+
+import (
+	"io/fs"
+
+	"plan9.io"
+)
+
+// sysStat returns 0, as the system has no inode numbers.
+func sysStat(fi fs.FileInfo, d *plan9.Dir) uint64 { return 0 }
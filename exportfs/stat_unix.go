@@ -0,0 +1,32 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package exportfs
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"plan9.io"
+)
+
+// sysStat sets the owner and group of d from the system's stat of fi and
+// returns its inode number, or 0 if fi has none.
+func sysStat(fi fs.FileInfo, d *plan9.Dir) uint64 {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	uid := strconv.FormatUint(uint64(st.Uid), 10)
+	if u, err := user.LookupId(uid); err == nil {
+		uid = u.Username
+	}
+	gid := strconv.FormatUint(uint64(st.Gid), 10)
+	if g, err := user.LookupGroupId(gid); err == nil {
+		gid = g.Name
+	}
+	d.UID, d.GID = uid, gid
+	return uint64(st.Ino)
+}
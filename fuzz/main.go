@@ -8,9 +8,8 @@ import (
 
 // Fuzz fuzzes fuzzy fuzzballs
 func Fuzz(data []byte) int {
-	_, err := plan9.Decode(bytes.NewReader(data))
-	if err != nil {
-		panic(err)
+	if _, err := plan9.Decode(bytes.NewReader(data)); err != nil {
+		return 0
 	}
 	return 1
 }
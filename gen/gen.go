@@ -13,20 +13,25 @@ import (
 	"golang.org/x/tools/imports"
 )
 
+// calls is the message table from intro(5), followed by the 9P2000.L and
+// 9P2000.e messages. Fields following a .u are only present when the
+// 9P2000.u or 9P2000.L dialect has been negotiated. Fields that would clash with Go
+// keywords or the Size method are renamed from the Linux documentation:
+// type is fstype or locktype, size is length or attrsize and dirfd is dirfid.
 const calls = `size[4] Tversion tag[2] msize[4] version[s]
 size[4] Rversion tag[2] msize[4] version[s]
-size[4] Tauth tag[2] afid[4] uname[s] aname[s]
+size[4] Tauth tag[2] afid[4] uname[s] aname[s] .u n_uname[4]
 size[4] Rauth tag[2] aqid[13]
-size[4] Rerror tag[2] ename[s]
+size[4] Rerror tag[2] ename[s] .u errno[4]
 size[4] Tflush tag[2] oldtag[2]
 size[4] Rflush tag[2]
-size[4] Tattach tag[2] fid[4] afid[4] uname[s] aname[s]
+size[4] Tattach tag[2] fid[4] afid[4] uname[s] aname[s] .u n_uname[4]
 size[4] Rattach tag[2] qid[13]
 size[4] Twalk tag[2] fid[4] newfid[4] nwname[2] nwname*(wname[s])
 size[4] Rwalk tag[2] nwqid[2] nwqid*(wqid[13])
 size[4] Topen tag[2] fid[4] mode[1]
 size[4] Ropen tag[2] qid[13] iounit[4]
-size[4] Tcreate tag[2] fid[4] name[s] perm[4] mode[1]
+size[4] Tcreate tag[2] fid[4] name[s] perm[4] mode[1] .u extension[s]
 size[4] Rcreate tag[2] qid[13] iounit[4]
 size[4] Tread tag[2] fid[4] offset[8] count[4]
 size[4] Rread tag[2] count[4] data[count]
@@ -39,7 +44,52 @@ size[4] Rremove tag[2]
 size[4] Tstat tag[2] fid[4]
 size[4] Rstat tag[2] stat[n]
 size[4] Twstat tag[2] fid[4] stat[n]
-size[4] Rwstat tag[2]`
+size[4] Rwstat tag[2]
+size[4] Rlerror tag[2] ecode[4]
+size[4] Tstatfs tag[2] fid[4]
+size[4] Rstatfs tag[2] fstype[4] bsize[4] blocks[8] bfree[8] bavail[8] files[8] ffree[8] fsid[8] namelen[4]
+size[4] Tlopen tag[2] fid[4] flags[4]
+size[4] Rlopen tag[2] qid[13] iounit[4]
+size[4] Tlcreate tag[2] fid[4] name[s] flags[4] mode[4] gid[4]
+size[4] Rlcreate tag[2] qid[13] iounit[4]
+size[4] Tsymlink tag[2] fid[4] name[s] symtgt[s] gid[4]
+size[4] Rsymlink tag[2] qid[13]
+size[4] Tmknod tag[2] dfid[4] name[s] mode[4] major[4] minor[4] gid[4]
+size[4] Rmknod tag[2] qid[13]
+size[4] Trename tag[2] fid[4] dfid[4] name[s]
+size[4] Rrename tag[2]
+size[4] Treadlink tag[2] fid[4]
+size[4] Rreadlink tag[2] target[s]
+size[4] Tgetattr tag[2] fid[4] request_mask[8]
+size[4] Rgetattr tag[2] valid[8] qid[13] mode[4] uid[4] gid[4] nlink[8] rdev[8] length[8] blksize[8] blocks[8] atime_sec[8] atime_nsec[8] mtime_sec[8] mtime_nsec[8] ctime_sec[8] ctime_nsec[8] btime_sec[8] btime_nsec[8] gen[8] data_version[8]
+size[4] Tsetattr tag[2] fid[4] valid[4] mode[4] uid[4] gid[4] length[8] atime_sec[8] atime_nsec[8] mtime_sec[8] mtime_nsec[8]
+size[4] Rsetattr tag[2]
+size[4] Txattrwalk tag[2] fid[4] newfid[4] name[s]
+size[4] Rxattrwalk tag[2] attrsize[8]
+size[4] Txattrcreate tag[2] fid[4] name[s] attr_size[8] flags[4]
+size[4] Rxattrcreate tag[2]
+size[4] Treaddir tag[2] fid[4] offset[8] count[4]
+size[4] Rreaddir tag[2] count[4] data[count]
+size[4] Tfsync tag[2] fid[4] datasync[4]
+size[4] Rfsync tag[2]
+size[4] Tlock tag[2] fid[4] locktype[1] flags[4] start[8] length[8] proc_id[4] client_id[s]
+size[4] Rlock tag[2] status[1]
+size[4] Tgetlock tag[2] fid[4] locktype[1] start[8] length[8] proc_id[4] client_id[s]
+size[4] Rgetlock tag[2] locktype[1] start[8] length[8] proc_id[4] client_id[s]
+size[4] Tlink tag[2] dfid[4] fid[4] name[s]
+size[4] Rlink tag[2]
+size[4] Tmkdir tag[2] dfid[4] name[s] mode[4] gid[4]
+size[4] Rmkdir tag[2] qid[13]
+size[4] Trenameat tag[2] olddirfid[4] oldname[s] newdirfid[4] newname[s]
+size[4] Rrenameat tag[2]
+size[4] Tunlinkat tag[2] dirfid[4] name[s] flags[4]
+size[4] Runlinkat tag[2]
+size[4] Tsession tag[2] key[8]
+size[4] Rsession tag[2]
+size[4] Tsread tag[2] fid[4] nwname[2] nwname*(wname[s])
+size[4] Rsread tag[2] count[4] data[count]
+size[4] Tswrite tag[2] fid[4] nwname[2] nwname*(wname[s]) count[4] data[count]
+size[4] Rswrite tag[2] count[4]`
 
 func main() {
 	scanner := bufio.NewScanner(strings.NewReader(calls))
@@ -50,6 +100,7 @@ func main() {
 	line := stract{}
 	lines := pkg{}
 	first := true
+	dotu := false
 	for scanner.Scan() {
 		t := scanner.Text()
 
@@ -57,14 +108,30 @@ func main() {
 			lines = append(lines, line)
 
 			line = stract{}
+			dotu = false
 		}
 		line.spec += " " + t
 		first = false
+		if t == ".u" {
+			dotu = true
+			continue
+		}
+
+		// a list, nwname*(wname[s]), replaces the count before it
+		if i := strings.Index(t, "*("); i > 0 {
+			elem := strings.TrimSuffix(t[i+2:], ")")
+			f := field{strings.Split(elem, "[")[0], -4, 0, "[]string", dotu}
+			if strings.HasSuffix(elem, "[13]") {
+				f = field{f.name, -5, 0, "[]plan9.QID", dotu}
+			}
+			line.fields[len(line.fields)-1] = f
+			continue
+		}
 
 		frags := strings.Split(t, "[")
 		fieldName, size := t, 1
 		if len(frags) > 1 {
-			fieldName = frags[0]
+			fieldName = camel(frags[0])
 			i, err := strconv.Atoi(strings.Trim(frags[1], "])"))
 			if err == nil {
 				size = i
@@ -78,7 +145,7 @@ func main() {
 				size = -2
 			}
 		}
-		line.fields = append(line.fields, field{fieldName, size, 0, ""})
+		line.fields = append(line.fields, field{fieldName, size, 0, "", dotu})
 
 	}
 	lines = append(lines, line)
@@ -115,26 +182,20 @@ func main() {
 			if f.size == 8 {
 				l.fields[i].typ = "uint64"
 			}
-			if strings.HasSuffix(l.fields[i].name, "fid") {
+			if f.size < 0 {
+				// lists and variable length fields are typed already
+			} else if strings.HasSuffix(l.fields[i].name, "fid") {
 				l.fields[i].typ = "plan9.FID"
-			}
-			if strings.HasSuffix(l.fields[i].name, "qid") {
+			} else if strings.HasSuffix(l.fields[i].name, "qid") {
 				l.fields[i].typ = "plan9.QID"
-			}
-			if strings.HasSuffix(l.fields[i].name, "tag") {
+			} else if strings.HasSuffix(l.fields[i].name, "tag") {
 				l.fields[i].typ = "plan9.Tag"
 			}
 
 			l.fields[i].offset = size
 			size = size + f.size
 		}
-		if lines[j].name == "Walk" {
-			if !lines[j].req {
-				lines[j].fields = append(l.fields[:len(l.fields)-2], field{"wname", -4, 0, "[]string"})
-			} else {
-				lines[j].fields = append(l.fields[:len(l.fields)-2], field{"wqid", -5, 0, "[]plan9.QID"})
-			}
-		}
+
 	}
 	buf := bytes.NewBuffer(nil)
 	io.WriteString(buf, "package plan9\n")
@@ -151,7 +212,11 @@ return &%s{header: h}
 	}
 	fmt.Fprintf(buf, `}
 return msg
-}`)
+}
+`)
+	for _, s := range lines {
+		fmt.Fprintln(buf, s.Encoder())
+	}
 	opts := imports.Options{
 		Fragment:  true,
 		AllErrors: false, // Report all errors (not just the first 10 on different lines)
@@ -192,14 +257,15 @@ type field struct {
 	size   int
 	offset int
 	typ    string
+	dotu   bool // only present in 9P2000.u
 }
 
 func (s stract) Enum() string {
-	req := "r"
+	req := "R"
 	if !s.req {
-		req = "t"
+		req = "T"
 	}
-	name := req + strings.ToLower(s.name)
+	name := "plan9." + req + strings.ToLower(s.name)
 	return name
 }
 func (s stract) Name() string {
@@ -229,95 +295,229 @@ func (s stract) String() string {
 type %s struct `, name, t+strings.ToLower(s.name), s.spec[1:], name)
 	fmt.Fprintln(buf, "{")
 	fmt.Fprintln(buf, "\theader\tHeader")
-	for _, f := range s.fields[3:] {
-		if ((s.name == "Read" && s.req) || (s.name == "Write" && !s.req)) && f.name == "count" {
-			continue
-		}
+	for _, f := range s.wireFields() {
 		fmt.Fprintln(buf, f)
 	}
 	fmt.Fprintln(buf, "}")
 	inital := strings.ToLower(name[:1])
+	s.getters(buf, inital, name)
+	s.unmarshaller(buf, inital, name)
+	s.marshaller(buf, inital, name)
+	return buf.String()
+}
+
+func (s stract) unmarshaller(buf io.Writer, inital, name string) {
 	fmt.Fprintf(buf, "func (%s *%s) UnmarshalBinary(data []byte) error{\n", inital, name)
-	offset := 0
-	nextByte := func() string {
-		defer func() { offset = offset + 1 }()
-		return fmt.Sprintf("data[%d]", offset)
+	fields := s.wireFields()
+	if len(fields) > 0 {
+		fmt.Fprintln(buf, "var err error")
+		fmt.Fprintln(buf, "b := data")
+	}
+	fail := func(field string) string {
+		return fmt.Sprintf("err != nil {\nreturn decodeError(%s, %q, data, b, err)\n}\n", s.Enum(), field)
 	}
-	nfileds := []field{}
-	for i, fyld := range s.fields[3:] {
+	for _, fyld := range fields {
+		if fyld.dotu {
+			fmt.Fprintf(buf, "if %s.header.dotu() {\n", inital)
+		}
 		switch fyld.size {
-		case -6:
-			nfileds = append(nfileds[:i-1], fyld)
+		case 1, 2, 4, 8:
+			typ := strings.Replace(fyld.typ, "plan9.", "", -1)
+			fmt.Fprintf(buf, "if %s.%s, b, err = g%s(b); %s", inital, fyld.name, strings.ToLower(typ), fail(fyld.name))
 		case 13:
-			nfileds = append(nfileds, field{fyld.name + ".Type", 1, fyld.offset + 12, "uint8"})
-			nfileds = append(nfileds, field{fyld.name + ".Vers", 4, fyld.offset + 8, "uint32"})
-			nfileds = append(nfileds, field{fyld.name + ".Path", 8, fyld.offset, "uint64"})
-		default:
-			nfileds = append(nfileds, fyld)
+			fmt.Fprintf(buf, "if %s.%s, b, err = gqid(b); %s", inital, fyld.name, fail(fyld.name))
+		case -1:
+			fmt.Fprintf(buf, "if %s.%s, b, err = gstring(b); %s", inital, fyld.name, fail(fyld.name))
+		case -3:
+			fmt.Fprintf(buf, "if %s.%s, b, err = unmarshalstat(b, %s.header.dotu()); %s", inital, fyld.name, inital, fail(fyld.name))
+		case -4, -5:
+			unmarshaller, typ := "gstring", "string"
+			if fyld.size == -5 {
+				unmarshaller, typ = "gqid", "plan9.QID"
+			}
+			fmt.Fprintf(buf, "var n uint16\n")
+			fmt.Fprintf(buf, "if n, b, err = guint16(b); %s", fail("n"+fyld.name))
+			fmt.Fprintf(buf, "for i:= uint16(0); i< n; i++{\n")
+			fmt.Fprintf(buf, "var x %s\n", typ)
+			fmt.Fprintf(buf, "if x, b, err = %s(b); %s", unmarshaller, fail(fyld.name))
+			fmt.Fprintf(buf, "%s.%s = append(%s.%s, x)\n", inital, fyld.name, inital, fyld.name)
+			fmt.Fprintf(buf, "}\n")
+		case -6:
+			fmt.Fprintf(buf, "var count uint32\n")
+			fmt.Fprintf(buf, "if count, b, err = guint32(b); %s", fail("count"))
+			fmt.Fprintf(buf, "if %s.%s, b, err = gdata(b, count); %s", inital, fyld.name, fail(fyld.name))
+		}
+		if fyld.dotu {
+			fmt.Fprintln(buf, "}")
+		}
+	}
+	if len(fields) > 0 {
+		fmt.Fprintf(buf, "if len(b) != 0 {\nreturn decodeError(%s, \"size\", data, b, ErrTrailingData)\n}\n", s.Enum())
+	} else {
+		fmt.Fprintf(buf, "if len(data) != 0 {\nreturn decodeError(%s, \"size\", data, data, ErrTrailingData)\n}\n", s.Enum())
+	}
+	fmt.Fprintln(buf, "return nil")
+	fmt.Fprintln(buf, "}")
+}
+
+// wireFields are the fields of s that are marshaled from struct fields,
+// leaving out counts that are implied by the length of the data that follows.
+func (s stract) wireFields() []field {
+	fields := s.fields[3:]
+	wire := []field{}
+	for i, fyld := range fields {
+		if fyld.name == "count" && i+1 < len(fields) && fields[i+1].size == -6 {
+			continue
+		}
+		wire = append(wire, fyld)
+	}
+	return wire
+}
+
+// camel converts a snake_case field name of the spec to camelCase, so
+// that request_mask has the getter RequestMask.
+func camel(name string) string {
+	words := strings.Split(name, "_")
+	for i, w := range words[1:] {
+		if w == "id" {
+			words[i+1] = "ID"
+		} else {
+			words[i+1] = strings.ToUpper(w[:1]) + w[1:]
 		}
 	}
-	for _, fyld := range nfileds {
-		typ := strings.Replace(fyld.typ, ".", "", -1)
-		typ = strings.Replace(typ, "[]", "", -1)
-		unmarshaller := ""
+	return strings.Join(words, "")
+}
+
+func (s stract) getters(buf io.Writer, inital, name string) {
+	fmt.Fprintf(buf, "\n// Header returns the header of the message.\n")
+	fmt.Fprintf(buf, "func (%s *%s) Header() Header { return %s.header }\n", inital, name, inital)
+	for _, fyld := range s.wireFields() {
+		getter := strings.ToUpper(fyld.name[:1]) + fyld.name[1:]
+		fmt.Fprintf(buf, "\n// %s returns the %s field of the message.\n", getter, fyld.name)
+		fmt.Fprintf(buf, "func (%s *%s) %s() %s { return %s.%s }\n", inital, name, getter, fyld.typ, inital, fyld.name)
+	}
+	fmt.Fprintln(buf)
+}
+
+func (s stract) sizer(buf io.Writer, inital, name string) {
+	fixed := 7
+	variable := []string{}
+	for _, fyld := range s.wireFields() {
+		if fyld.dotu {
+			n := fmt.Sprint(fyld.size)
+			if fyld.size == -1 {
+				n = fmt.Sprintf("2 + len(%s.%s)", inital, fyld.name)
+			}
+			variable = append(variable, fmt.Sprintf("if %s.header.dotu() {n += %s}", inital, n))
+			continue
+		}
 		switch fyld.size {
-		case 1, 2, 4, 8:
-			/*
-				s := []string{}
-				for i := 0; i < fyld.size; i++ {
-					x := nextByte()
-					x = fmt.Sprintf("%s(%s)", fyld.typ, x)
-					if i > 0 {
-						x = fmt.Sprintf("%s<<%d", x, i*8)
-					}
+		case 1, 2, 4, 8, 13:
+			fixed += fyld.size
+		case -1:
+			fixed += 2
+			variable = append(variable, fmt.Sprintf("n += len(%s.%s)", inital, fyld.name))
+		case -3:
+			variable = append(variable, fmt.Sprintf("n += statsize(%s.%s, %s.header.dotu())", inital, fyld.name, inital))
+		case -4:
+			fixed += 2
+			variable = append(variable, fmt.Sprintf("for _, x := range %s.%s {n += 2 + len(x)}", inital, fyld.name))
+		case -5:
+			fixed += 2
+			variable = append(variable, fmt.Sprintf("n += 13 * len(%s.%s)", inital, fyld.name))
+		case -6:
+			fixed += 4
+			variable = append(variable, fmt.Sprintf("n += len(%s.%s)", inital, fyld.name))
+		}
+	}
+	fmt.Fprintf(buf, "\n// Size returns the size of the message on the wire.\n")
+	fmt.Fprintf(buf, "func (%s *%s) Size() plan9.Size {\n", inital, name)
+	if len(variable) == 0 {
+		fmt.Fprintf(buf, "return %d\n}\n", fixed)
+		return
+	}
+	fmt.Fprintf(buf, "n := %d\n", fixed)
+	for _, v := range variable {
+		fmt.Fprintln(buf, v)
+	}
+	fmt.Fprintln(buf, "return plan9.Size(n)")
+	fmt.Fprintln(buf, "}")
+}
 
-					s = append(s, x)
-				}
-				unmarshaller = strings.Join(s, "|")
-			*/
+func (s stract) marshaller(buf io.Writer, inital, name string) {
+	s.sizer(buf, inital, name)
+	fmt.Fprintf(buf, "\nfunc (%s *%s) MarshalBinary() ([]byte, error){\n", inital, name)
+	fmt.Fprintf(buf, "return %s.appendBinary(make([]byte, 0, %s.Size()))\n", inital, inital)
+	fmt.Fprintln(buf, "}")
+	fmt.Fprintf(buf, "\nfunc (%s *%s) appendBinary(b []byte) ([]byte, error){\n", inital, name)
+	fmt.Fprintln(buf, "n := len(b)")
+	fields := s.wireFields()
+	for _, fyld := range fields {
+		if fyld.size == -1 || fyld.size == -3 || fyld.size == -4 {
+			fmt.Fprintln(buf, "var err error")
+			break
+		}
+	}
+	fail := "err != nil {\nreturn nil, err\n}\n"
+	fmt.Fprintf(buf, "b = pheader(b, %s, %s.header.tag)\n", s.Enum(), inital)
+	for _, fyld := range fields {
+		if fyld.dotu {
+			fmt.Fprintf(buf, "if %s.header.dotu() {\n", inital)
+		}
+		switch fyld.size {
+		case 1, 2, 4, 8:
 			typ := strings.Replace(fyld.typ, "plan9.", "", -1)
-			unmarshaller = fmt.Sprintf("g%s(data)", strings.ToLower(typ))
-			fmt.Fprintf(buf, "%s.%s, data=%s\n", inital, fyld.name, unmarshaller)
+			marshaller := fmt.Sprintf("pbit%d", fyld.size*8)
+			if typ != fyld.typ {
+				marshaller = "p" + strings.ToLower(typ)
+			}
+			fmt.Fprintf(buf, "b = %s(b, %s.%s)\n", marshaller, inital, fyld.name)
+		case 13:
+			fmt.Fprintf(buf, "b = pqid(b, %s.%s)\n", inital, fyld.name)
 		case -1:
-			unmarshaller = "gstring(data)"
-			fmt.Fprintf(buf, "%s.%s, data=%s\n", inital, fyld.name, unmarshaller)
-			continue
+			fmt.Fprintf(buf, "if b, err = pstring(b, %s.%s); %s", inital, fyld.name, fail)
 		case -3:
-			unmarshaller = "unmarshaldir(data)"
-			fmt.Fprintf(buf, "_, data = guint16(data) // BUG(sevki): see https://9p.io/magic/man2html/5/stat\n")
-			fmt.Fprintf(buf, "%s.%s, data=%s\n", inital, fyld.name, unmarshaller)
+			fmt.Fprintf(buf, "if b, err = marshalstat(b, %s.%s, %s.header.dotu()); %s", inital, fyld.name, inital, fail)
 		case -4:
-			unmarshaller = "gstring(data)"
-			fmt.Fprintf(buf, "var nwname uint16;nwname, data=guint16(data)\n")
-			fmt.Fprintf(buf, "for i:= uint16(0); i< nwname; i++{\n")
-			fmt.Fprintf(buf, "var s string;s, data = gstring(data);%s.%s = append(%s.%s , s)", inital, fyld.name, inital, fyld.name)
-			fmt.Fprintf(buf, "}\n")
-			continue
+			fmt.Fprintf(buf, "b = pbit16(b, uint16(len(%s.%s)))\n", inital, fyld.name)
+			fmt.Fprintf(buf, "for _, x := range %s.%s {\nif b, err = pstring(b, x); %s}\n", inital, fyld.name, fail)
 		case -5:
-			unmarshaller = "gstring(data)"
-			fmt.Fprintf(buf, "var nwname uint16;nwname, data=guint16(data)\n")
-			fmt.Fprintf(buf, "for i:= uint16(0); i< nwname; i++{\n")
-			fmt.Fprintf(buf, "var q plan9.QID;")
-			fmt.Fprintf(buf, "q.Type, data = guint8(data);")
-			fmt.Fprintf(buf, "q.Vers, data = guint32(data);")
-			fmt.Fprintf(buf, "q.Path, data = guint64(data);")
-			fmt.Fprintf(buf, "%s.%s = append(%s.%s , q)", inital, fyld.name, inital, fyld.name)
-			fmt.Fprintf(buf, "}\n")
-			continue
+			fmt.Fprintf(buf, "b = pbit16(b, uint16(len(%s.%s)))\n", inital, fyld.name)
+			fmt.Fprintf(buf, "for _, x := range %s.%s {b = pqid(b, x)}\n", inital, fyld.name)
 		case -6:
-			unmarshaller = "gstring(data)"
-			fmt.Fprintf(buf, "var count uint32;count , data=guint32(data)\n")
-			fmt.Fprintf(buf, "%s.%s = data[:count ]\n", inital, fyld.name)
-			continue
-		default:
-			for i := 0; i < fyld.size; i++ {
-				nextByte()
-			}
-			continue
+			fmt.Fprintf(buf, "b = pbit32(b, uint32(len(%s.%s)))\n", inital, fyld.name)
+			fmt.Fprintf(buf, "b = append(b, %s.%s...)\n", inital, fyld.name)
 		}
+		if fyld.dotu {
+			fmt.Fprintln(buf, "}")
+		}
+	}
+	fmt.Fprintln(buf, "psize(b[n:])")
+	fmt.Fprintln(buf, "return b, nil")
+	fmt.Fprintln(buf, "}")
+}
 
+// Encoder writes the Encoder method for s.
+func (s stract) Encoder() string {
+	buf := bytes.NewBuffer(nil)
+	method := strings.TrimPrefix(s.Enum(), "plan9.")
+	article := "a"
+	if s.req {
+		article = "an"
 	}
-	fmt.Fprintln(buf, "return nil")
+	params := []string{"tag plan9.Tag"}
+	values := []string{"header: Header{tag: tag, dialect: e.dialect}"}
+	for _, fyld := range s.wireFields() {
+		params = append(params, fyld.name+" "+fyld.typ)
+		values = append(values, fyld.name+": "+fyld.name)
+	}
+	fmt.Fprintf(buf, "// %s writes %s %s message.\n", method, article, method)
+	fmt.Fprintf(buf, "func (e *Encoder) %s(%s) error {\n", method, strings.Join(params, ", "))
+	fmt.Fprintf(buf, "m := %s{%s}\n", s.Name(), strings.Join(values, ", "))
+	fmt.Fprintln(buf, "b, err := m.appendBinary(e.buf[:0])")
+	fmt.Fprintln(buf, "if err != nil {\nreturn err\n}")
+	fmt.Fprintln(buf, "e.buf = b")
+	fmt.Fprintln(buf, "return e.write()")
 	fmt.Fprintln(buf, "}")
 	return buf.String()
 }
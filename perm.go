@@ -0,0 +1,68 @@
+package plan9
+
+import "io/fs"
+
+// String returns the mode as printed by ls(1), e.g. "d-rwxr-xr-x".
+func (p Perm) String() string {
+	b := []byte("-----------")
+	switch {
+	case p&DMDIR != 0:
+		b[0] = 'd'
+	case p&DMAPPEND != 0:
+		b[0] = 'a'
+	case p&DMAUTH != 0:
+		b[0] = 'A'
+	}
+	if p&DMEXCL != 0 {
+		b[1] = 'l'
+	}
+	for i, c := range "rwxrwxrwx" {
+		if p&(1<<uint(8-i)) != 0 {
+			b[2+i] = byte(c)
+		}
+	}
+	return string(b)
+}
+
+// modeBits pairs the mode bits with their fs.FileMode counterparts.
+var modeBits = []struct {
+	perm Perm
+	mode fs.FileMode
+}{
+	{DMDIR, fs.ModeDir},
+	{DMAPPEND, fs.ModeAppend},
+	{DMEXCL, fs.ModeExclusive},
+	{DMTMP, fs.ModeTemporary},
+	{DMSYMLINK, fs.ModeSymlink},
+	{DMDEVICE, fs.ModeDevice},
+	{DMNAMEDPIPE, fs.ModeNamedPipe},
+	{DMSOCKET, fs.ModeSocket},
+	{DMSETUID, fs.ModeSetuid},
+	{DMSETGID, fs.ModeSetgid},
+	{DMSETVTX, fs.ModeSticky},
+}
+
+// FileMode converts p to an fs.FileMode. DMMOUNT and DMAUTH have no
+// counterparts and are dropped.
+func (p Perm) FileMode() fs.FileMode {
+	mode := fs.FileMode(p & 0777)
+	for _, b := range modeBits {
+		if p&b.perm != 0 {
+			mode |= b.mode
+		}
+	}
+	return mode
+}
+
+// FromFileMode converts an fs.FileMode, such as an os.FileMode, to a Perm.
+// It is the inverse of Perm.FileMode; fs.ModeCharDevice and
+// fs.ModeIrregular have no counterparts and are dropped.
+func FromFileMode(mode fs.FileMode) Perm {
+	p := Perm(mode.Perm())
+	for _, b := range modeBits {
+		if mode&b.mode != 0 {
+			p |= b.perm
+		}
+	}
+	return p
+}
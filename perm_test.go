@@ -0,0 +1,35 @@
+package plan9
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+)
+
+func TestPerm(t *testing.T) {
+	tests := []struct {
+		perm Perm
+		s    string
+		mode fs.FileMode
+	}{
+		{0644, "--rw-r--r--", 0644},
+		{DMDIR | 0755, "d-rwxr-xr-x", fs.ModeDir | 0755},
+		{DMAPPEND | DMWRITE<<6 | DMWRITE<<3 | DMWRITE, "a--w--w--w-", fs.ModeAppend | 0222},
+		{DMEXCL | DMTMP | DMREAD<<6 | DMWRITE<<6, "-lrw-------", fs.ModeExclusive | fs.ModeTemporary | 0600},
+		{DMAUTH | 0600, "A-rw-------", 0600},
+		{DMSYMLINK | 0777, "--rwxrwxrwx", fs.ModeSymlink | 0777},
+		{DMDEVICE | DMSETUID | DMSETGID | DMSETVTX | 0755, "--rwxr-xr-x", fs.ModeDevice | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky | 0755},
+		{DMNAMEDPIPE | DMEXEC, "----------x", fs.ModeNamedPipe | 01},
+		{DMSOCKET, "-----------", fs.ModeSocket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.perm.String(), tt.s)
+			assert.Equal(t, tt.perm.FileMode(), tt.mode)
+			if tt.perm&(DMMOUNT|DMAUTH) == 0 {
+				assert.Equal(t, FromFileMode(tt.mode), tt.perm)
+			}
+		})
+	}
+}
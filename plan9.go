@@ -1,5 +1,11 @@
 package plan9
 
+import (
+	"fmt"
+	"strconv"
+)
+
+// Perm is the mode of a file: its type bits and permissions.
 type Perm uint32
 
 // Size is the type we use to encode 9P2000
@@ -21,19 +27,131 @@ const (
 	IOHDRSZ = 24
 	// DefaultVersion is the 9pversion
 	DefaultVersion = "9P2000"
+	// VersionU is the version of 9P2000 with the Unix extensions
+	VersionU = "9P2000.u"
+	// VersionL is the version of 9P2000 spoken by the Linux v9fs client
+	VersionL = "9P2000.L"
 
 	NoTag Tag = ^Tag(0)
 	NoFID     = 0xffffffff
 	NoUID     = 0xffffffff
 )
 
+// Mode bits of a Dir.
+const (
+	DMDIR    Perm = 0x80000000 // mode bit for directories
+	DMAPPEND Perm = 0x40000000 // mode bit for append only files
+	DMEXCL   Perm = 0x20000000 // mode bit for exclusive use files
+	DMMOUNT  Perm = 0x10000000 // mode bit for mounted channel
+	DMAUTH   Perm = 0x08000000 // mode bit for authentication file
+	DMTMP    Perm = 0x04000000 // mode bit for non-backed-up files
+
+	DMREAD  Perm = 0x4 // mode bit for read permission
+	DMWRITE Perm = 0x2 // mode bit for write permission
+	DMEXEC  Perm = 0x1 // mode bit for execute permission
+)
+
+// 9P2000.u mode bits of a Dir.
+const (
+	DMSYMLINK   Perm = 0x02000000 // mode bit for symbolic links
+	DMDEVICE    Perm = 0x00800000 // mode bit for devices
+	DMNAMEDPIPE Perm = 0x00200000 // mode bit for named pipes
+	DMSOCKET    Perm = 0x00100000 // mode bit for sockets
+	DMSETUID    Perm = 0x00080000 // mode bit for setuid
+	DMSETGID    Perm = 0x00040000 // mode bit for setgid
+	DMSETVTX    Perm = 0x00010000 // mode bit for the sticky bit
+)
+
+// Open modes for Topen and Tcreate.
+const (
+	OREAD   = 0  // open for read
+	OWRITE  = 1  // write
+	ORDWR   = 2  // read and write
+	OEXEC   = 3  // execute, == read but check execute permission
+	OTRUNC  = 16 // or'ed in (except for exec), truncate file first
+	ORCLOSE = 64 // or'ed in, remove on close
+)
+
+// Dialect is the variant of the protocol negotiated with Tversion.
+type Dialect uint8
+
+const (
+	Dialect9P2000  Dialect = iota // 9P2000, as described in intro(5)
+	Dialect9P2000u                // 9P2000.u, adding numeric ids, errno and special files
+	Dialect9P2000L                // 9P2000.L, the 9P2000.u fields plus the Linux messages
+)
+
+// ParseDialect returns the dialect named by a Tversion version string.
+func ParseDialect(version string) (Dialect, error) {
+	switch version {
+	case DefaultVersion:
+		return Dialect9P2000, nil
+	case VersionU:
+		return Dialect9P2000u, nil
+	case VersionL:
+		return Dialect9P2000L, nil
+	}
+	return 0, fmt.Errorf("9p: unknown version %q", version)
+}
+
+// String returns the version string of the dialect.
+func (d Dialect) String() string {
+	switch d {
+	case Dialect9P2000:
+		return DefaultVersion
+	case Dialect9P2000u:
+		return VersionU
+	case Dialect9P2000L:
+		return VersionL
+	}
+	return "Dialect(" + strconv.Itoa(int(d)) + ")"
+}
+
 // A QID represents a 9P server's unique identification for a file.
 type QID struct {
 	Path uint64 // the file server's unique identification for the file
 	Vers uint32 // version number for given Path
-	Type uint8  // the type of the file (syscall.QTDIR for example)
+	Type uint8  // the type of the file (QTDIR for example)
+}
+
+// QID types, the high byte of the mode of the file.
+const (
+	QTDIR     = 0x80 // type bit for directories
+	QTAPPEND  = 0x40 // type bit for append only files
+	QTEXCL    = 0x20 // type bit for exclusive use files
+	QTMOUNT   = 0x10 // type bit for mounted channel
+	QTAUTH    = 0x08 // type bit for authentication file
+	QTTMP     = 0x04 // type bit for non-backed-up file
+	QTSYMLINK = 0x02 // type bit for symbolic links, in 9P2000.u
+	QTFILE    = 0x00 // plain file
+)
+
+// The predicates report whether q has a type bit.
+func (q QID) IsDir() bool    { return q.Type&QTDIR != 0 }
+func (q QID) IsAppend() bool { return q.Type&QTAPPEND != 0 }
+func (q QID) IsExcl() bool   { return q.Type&QTEXCL != 0 }
+func (q QID) IsMount() bool  { return q.Type&QTMOUNT != 0 }
+func (q QID) IsAuth() bool   { return q.Type&QTAUTH != 0 }
+func (q QID) IsTmp() bool    { return q.Type&QTTMP != 0 }
+
+// String returns q as printed by Plan 9's fcall(2) formats, e.g.
+// "(0000000000000001 0 d)".
+func (q QID) String() string {
+	var t []byte
+	for _, c := range []struct {
+		bit uint8
+		c   byte
+	}{{QTDIR, 'd'}, {QTAPPEND, 'a'}, {QTEXCL, 'l'}, {QTAUTH, 'A'}} {
+		if q.Type&c.bit != 0 {
+			t = append(t, c.c)
+		}
+	}
+	return fmt.Sprintf("(%.16x %d %s)", q.Path, q.Vers, t)
 }
 
+// QIDType returns the QID type of a file with the mode p.
+func (p Perm) QIDType() uint8 { return uint8(p >> 24) }
+
 // A Dir contains the metadata for a file.
 type Dir struct {
 	// system-modified data
@@ -50,9 +168,208 @@ type Dir struct {
 	UID    string // owner name
 	GID    string // group name
 	Muid   string // last modifier name
+
+	// 9P2000.u extensions
+	Extension string // data about special files, e.g. a symlink's target
+	NUID      uint32 // numeric owner id
+	NGID      uint32 // numeric group id
+	NMUID     uint32 // numeric last modifier id
+}
+
+// Check returns an error if the QID type of d disagrees with its mode.
+func (d *Dir) Check() error {
+	if d.QID.Type != d.Mode.QIDType() {
+		return fmt.Errorf("9p: qid type %#x of %q disagrees with mode %v", d.QID.Type, d.Name, d.Mode)
+	}
+	return nil
+}
+
+// Message types.
+const (
+	Tversion MessageType = 100 + iota
+	Rversion
+	Tauth
+	Rauth
+	Tattach
+	Rattach
+	Terror // illegal
+	Rerror
+	Tflush
+	Rflush
+	Twalk
+	Rwalk
+	Topen
+	Ropen
+	Tcreate
+	Rcreate
+	Tread
+	Rread
+	Twrite
+	Rwrite
+	Tclunk
+	Rclunk
+	Tremove
+	Rremove
+	Tstat
+	Rstat
+	Twstat
+	Rwstat
+)
+
+// 9P2000.L message types.
+const (
+	Tlerror      MessageType = 6 // illegal
+	Rlerror      MessageType = 7
+	Tstatfs      MessageType = 8
+	Rstatfs      MessageType = 9
+	Tlopen       MessageType = 12
+	Rlopen       MessageType = 13
+	Tlcreate     MessageType = 14
+	Rlcreate     MessageType = 15
+	Tsymlink     MessageType = 16
+	Rsymlink     MessageType = 17
+	Tmknod       MessageType = 18
+	Rmknod       MessageType = 19
+	Trename      MessageType = 20
+	Rrename      MessageType = 21
+	Treadlink    MessageType = 22
+	Rreadlink    MessageType = 23
+	Tgetattr     MessageType = 24
+	Rgetattr     MessageType = 25
+	Tsetattr     MessageType = 26
+	Rsetattr     MessageType = 27
+	Txattrwalk   MessageType = 30
+	Rxattrwalk   MessageType = 31
+	Txattrcreate MessageType = 32
+	Rxattrcreate MessageType = 33
+	Treaddir     MessageType = 40
+	Rreaddir     MessageType = 41
+	Tfsync       MessageType = 50
+	Rfsync       MessageType = 51
+	Tlock        MessageType = 52
+	Rlock        MessageType = 53
+	Tgetlock     MessageType = 54
+	Rgetlock     MessageType = 55
+	Tlink        MessageType = 70
+	Rlink        MessageType = 71
+	Tmkdir       MessageType = 72
+	Rmkdir       MessageType = 73
+	Trenameat    MessageType = 74
+	Rrenameat    MessageType = 75
+	Tunlinkat    MessageType = 76
+	Runlinkat    MessageType = 77
+)
+
+// 9P2000.e message types.
+const (
+	Tsession MessageType = 150 + iota
+	Rsession
+	Tsread
+	Rsread
+	Tswrite
+	Rswrite
+)
+
+var messageTypes = [...]string{
+	Tlerror:      "Tlerror",
+	Rlerror:      "Rlerror",
+	Tstatfs:      "Tstatfs",
+	Rstatfs:      "Rstatfs",
+	Tlopen:       "Tlopen",
+	Rlopen:       "Rlopen",
+	Tlcreate:     "Tlcreate",
+	Rlcreate:     "Rlcreate",
+	Tsymlink:     "Tsymlink",
+	Rsymlink:     "Rsymlink",
+	Tmknod:       "Tmknod",
+	Rmknod:       "Rmknod",
+	Trename:      "Trename",
+	Rrename:      "Rrename",
+	Treadlink:    "Treadlink",
+	Rreadlink:    "Rreadlink",
+	Tgetattr:     "Tgetattr",
+	Rgetattr:     "Rgetattr",
+	Tsetattr:     "Tsetattr",
+	Rsetattr:     "Rsetattr",
+	Txattrwalk:   "Txattrwalk",
+	Rxattrwalk:   "Rxattrwalk",
+	Txattrcreate: "Txattrcreate",
+	Rxattrcreate: "Rxattrcreate",
+	Treaddir:     "Treaddir",
+	Rreaddir:     "Rreaddir",
+	Tfsync:       "Tfsync",
+	Rfsync:       "Rfsync",
+	Tlock:        "Tlock",
+	Rlock:        "Rlock",
+	Tgetlock:     "Tgetlock",
+	Rgetlock:     "Rgetlock",
+	Tlink:        "Tlink",
+	Rlink:        "Rlink",
+	Tmkdir:       "Tmkdir",
+	Rmkdir:       "Rmkdir",
+	Trenameat:    "Trenameat",
+	Rrenameat:    "Rrenameat",
+	Tunlinkat:    "Tunlinkat",
+	Runlinkat:    "Runlinkat",
+	Tversion:     "Tversion",
+	Rversion:     "Rversion",
+	Tauth:        "Tauth",
+	Rauth:        "Rauth",
+	Tattach:      "Tattach",
+	Rattach:      "Rattach",
+	Terror:       "Terror",
+	Rerror:       "Rerror",
+	Tflush:       "Tflush",
+	Rflush:       "Rflush",
+	Twalk:        "Twalk",
+	Rwalk:        "Rwalk",
+	Topen:        "Topen",
+	Ropen:        "Ropen",
+	Tcreate:      "Tcreate",
+	Rcreate:      "Rcreate",
+	Tread:        "Tread",
+	Rread:        "Rread",
+	Twrite:       "Twrite",
+	Rwrite:       "Rwrite",
+	Tclunk:       "Tclunk",
+	Rclunk:       "Rclunk",
+	Tremove:      "Tremove",
+	Rremove:      "Rremove",
+	Tstat:        "Tstat",
+	Rstat:        "Rstat",
+	Twstat:       "Twstat",
+	Rwstat:       "Rwstat",
+	Tsession:     "Tsession",
+	Rsession:     "Rsession",
+	Tsread:       "Tsread",
+	Rsread:       "Rsread",
+	Tswrite:      "Tswrite",
+	Rswrite:      "Rswrite",
+}
+
+// String returns the name of the message type as written in intro(5), e.g. Tversion.
+func (t MessageType) String() string {
+	if int(t) < len(messageTypes) && messageTypes[t] != "" {
+		return messageTypes[t]
+	}
+	return "MessageType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// IsRequest reports whether t is a T-message, sent from client to server.
+func (t MessageType) IsRequest() bool { return t%2 == 0 }
+
+// Response returns the type of the R-message answering t.
+// If t is already a response it is returned unchanged.
+func (t MessageType) Response() MessageType {
+	if t.IsRequest() {
+		return t + 1
+	}
+	return t
 }
 
 // Message represents a 9P message
 type Message interface {
+	// Size returns the number of bytes the message occupies on the wire,
+	// including the size[4] field itself.
 	Size() Size
 }
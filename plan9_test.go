@@ -0,0 +1,105 @@
+package plan9
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+)
+
+func TestMessageType(t *testing.T) {
+	tests := []struct {
+		t         MessageType
+		name      string
+		isRequest bool
+		response  MessageType
+	}{
+		{Tversion, "Tversion", true, Rversion},
+		{Rversion, "Rversion", false, Rversion},
+		{Terror, "Terror", true, Rerror},
+		{Twalk, "Twalk", true, Rwalk},
+		{Rwstat, "Rwstat", false, Rwstat},
+		{Rlerror, "Rlerror", false, Rlerror},
+		{Treaddir, "Treaddir", true, Rreaddir},
+		{Runlinkat, "Runlinkat", false, Runlinkat},
+		{Tsession, "Tsession", true, Rsession},
+		{Rswrite, "Rswrite", false, Rswrite},
+		{MessageType(0), "MessageType(0)", true, MessageType(1)},
+		{MessageType(255), "MessageType(255)", false, MessageType(255)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.t.String(), tt.name)
+			assert.Equal(t, tt.t.IsRequest(), tt.isRequest)
+			assert.Equal(t, tt.t.Response(), tt.response)
+		})
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Dialect
+		wantErr bool
+	}{
+		{"9P2000", Dialect9P2000, false},
+		{"9P2000.u", Dialect9P2000u, false},
+		{"9P2000.L", Dialect9P2000L, false},
+		{"9P2000.x", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseDialect(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDialect(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+			assert.Equal(t, got, tt.want)
+			if !tt.wantErr {
+				assert.Equal(t, got.String(), tt.version)
+			}
+		})
+	}
+}
+
+func TestQID(t *testing.T) {
+	tests := []struct {
+		qid   QID
+		s     string
+		isDir bool
+	}{
+		{QID{Path: 1, Type: QTDIR}, "(0000000000000001 0 d)", true},
+		{QID{Path: 0xdeadbeef, Vers: 42, Type: QTFILE}, "(00000000deadbeef 42 )", false},
+		{QID{Path: 2, Vers: 1, Type: QTAPPEND | QTEXCL}, "(0000000000000002 1 al)", false},
+		{QID{Type: QTAUTH | QTTMP}, "(0000000000000000 0 A)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.qid.String(), tt.s)
+			assert.Equal(t, tt.qid.IsDir(), tt.isDir)
+			assert.Equal(t, tt.qid.IsAppend(), tt.qid.Type&QTAPPEND != 0)
+			assert.Equal(t, tt.qid.IsTmp(), tt.qid.Type&QTTMP != 0)
+		})
+	}
+}
+
+func TestDirCheck(t *testing.T) {
+	tests := []struct {
+		mode Perm
+		typ  uint8
+		ok   bool
+	}{
+		{0644, QTFILE, true},
+		{DMDIR | 0755, QTDIR, true},
+		{DMAPPEND | DMEXCL | 0600, QTAPPEND | QTEXCL, true},
+		{DMSYMLINK | 0777, QTSYMLINK, true},
+		{DMDIR | 0755, QTFILE, false},
+		{0644, QTDIR, false},
+		{DMTMP | 0644, QTFILE, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			d := Dir{Name: "x", Mode: tt.mode, QID: QID{Type: tt.typ}}
+			assert.Equal(t, d.Check() == nil, tt.ok)
+		})
+	}
+}
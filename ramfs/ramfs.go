@@ -0,0 +1,442 @@
+// Package ramfs is a file server keeping its files in memory, like Plan 9's
+// ramfs(4). It is a server.Handler, checking the permissions of the users
+// attached to it.
+package ramfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"plan9.io"
+	"plan9.io/server"
+)
+
+var (
+	errExist    = errors.New("file exists")
+	errNotExist = errors.New("file does not exist")
+	errNotEmpty = errors.New("directory not empty")
+	errRemoved  = errors.New("file has been removed")
+	errExcl     = errors.New("exclusive use file already open")
+	errTooBig   = errors.New("file too large")
+)
+
+// maxLength is the largest a file may grow, as it is held in memory.
+const maxLength = 1 << 30
+
+// FS is a tree of files in memory. It is safe for concurrent use.
+type FS struct {
+	mu   sync.RWMutex // guards the tree
+	root *node
+	path uint64 // the qid path of the last file created
+}
+
+// node is a file of the tree.
+type node struct {
+	d        plan9.Dir
+	parent   *node   // nil once removed, the root for the root
+	children []*node // sorted by name
+	data     []byte
+	opens    int // the fids the file is open on
+}
+
+// New returns an FS holding an empty directory owned by uid.
+func New(uid string) *FS {
+	fsys := new(FS)
+	fsys.root = fsys.newNode("/", plan9.DMDIR|0777, uid, uid)
+	fsys.root.parent = fsys.root
+	return fsys
+}
+
+func (fsys *FS) newNode(name string, perm plan9.Perm, uid, gid string) *node {
+	fsys.path++
+	now := uint32(time.Now().Unix())
+	return &node{d: plan9.Dir{
+		QID:   plan9.QID{Type: perm.QIDType(), Path: fsys.path},
+		Mode:  perm,
+		Atime: now,
+		Mtime: now,
+		Name:  name,
+		UID:   uid,
+		GID:   gid,
+		Muid:  uid,
+	}}
+}
+
+func (n *node) isDir() bool { return n.d.Mode&plan9.DMDIR != 0 }
+
+// lookup returns the child of n called name.
+func (n *node) lookup(name string) *node {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].d.Name >= name })
+	if i < len(n.children) && n.children[i].d.Name == name {
+		return n.children[i]
+	}
+	return nil
+}
+
+// link adds c to the children of n.
+func (n *node) link(c *node) {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].d.Name >= c.d.Name })
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = c
+	c.parent = n
+}
+
+// unlink removes c from the children of n.
+func (n *node) unlink(c *node) {
+	for i, cc := range n.children {
+		if cc == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			break
+		}
+	}
+	c.parent = nil
+}
+
+// modified records a change to n by uid.
+func (n *node) modified(uid string) {
+	n.d.Mtime = uint32(time.Now().Unix())
+	n.d.Muid = uid
+	n.d.QID.Vers++
+}
+
+// hasPerm reports whether uid may access n for p, by the owner, group or
+// other bits of its mode. The group of a user is the one named after them.
+func (n *node) hasPerm(uid string, p plan9.Perm) bool {
+	m := n.d.Mode & 7
+	if uid == n.d.UID {
+		m |= n.d.Mode >> 6 & 7
+	}
+	if uid == n.d.GID {
+		m |= n.d.Mode >> 3 & 7
+	}
+	return m&p == p
+}
+
+// WriteFile creates the file name, a slash-separated path from the root
+// with or without a leading slash, with the contents data and the
+// permissions perm, owned by the owner of the root. Missing directories are
+// created with the permissions 0777. Names that no walk could reach, such
+// as "." or those with ".." elements, are refused.
+func (fsys *FS) WriteFile(name string, data []byte, perm plan9.Perm) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	uid := fsys.root.d.UID
+	dir := fsys.root
+	elems := strings.Split(strings.TrimPrefix(path.Clean(name), "/"), "/")
+	for _, elem := range elems {
+		if err := server.CheckName(elem); err != nil {
+			return err
+		}
+	}
+	for _, elem := range elems[:len(elems)-1] {
+		n := dir.lookup(elem)
+		if n == nil {
+			n = fsys.newNode(elem, plan9.DMDIR|0777, uid, uid)
+			dir.link(n)
+		}
+		if !n.isDir() {
+			return errExist
+		}
+		dir = n
+	}
+	n := dir.lookup(elems[len(elems)-1])
+	if n == nil {
+		n = fsys.newNode(elems[len(elems)-1], perm&^plan9.DMDIR, uid, uid)
+		dir.link(n)
+	}
+	if n.isDir() {
+		return server.ErrIsDir
+	}
+	n.data = append([]byte(nil), data...)
+	n.d.Length = uint64(len(data))
+	n.modified(uid)
+	return nil
+}
+
+// file is the Aux of a fid.
+type file struct {
+	n      *node
+	rclose bool
+	dirs   server.DirList
+}
+
+func aux(fid *server.Fid) *file { return fid.Aux.(*file) }
+
+func (fsys *FS) Attach(ctx context.Context, fid, afid *server.Fid, uname, aname string) (plan9.QID, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+	fid.Aux = &file{n: fsys.root}
+	return fsys.root.d.QID, nil
+}
+
+func (fsys *FS) Walk(ctx context.Context, fid, newfid *server.Fid, names []string) ([]plan9.QID, error) {
+	// not RLock: a fid walked to itself changes its file
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	n := aux(fid).n
+	if n.parent == nil {
+		return nil, errRemoved
+	}
+	var qids []plan9.QID
+	for _, name := range names {
+		var next *node
+		switch {
+		case !n.hasPerm(fid.Uname(), plan9.DMEXEC):
+			if len(qids) == 0 {
+				return nil, server.ErrPerm
+			}
+		case name == "..":
+			next = n.parent
+		default:
+			next = n.lookup(name)
+		}
+		if next == nil {
+			if len(qids) == 0 {
+				return nil, errNotExist
+			}
+			break
+		}
+		n = next
+		qids = append(qids, n.d.QID)
+	}
+	switch {
+	case len(qids) < len(names):
+	case newfid == fid:
+		aux(fid).n = n
+	default:
+		newfid.Aux = &file{n: n}
+	}
+	return qids, nil
+}
+
+func (fsys *FS) Open(ctx context.Context, fid *server.Fid, mode uint8) (plan9.QID, uint32, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	f := aux(fid)
+	n := f.n
+	if n.parent == nil {
+		return plan9.QID{}, 0, errRemoved
+	}
+	var p plan9.Perm
+	switch mode & 3 {
+	case plan9.OREAD:
+		p = plan9.DMREAD
+	case plan9.OWRITE:
+		p = plan9.DMWRITE
+	case plan9.ORDWR:
+		p = plan9.DMREAD | plan9.DMWRITE
+	case plan9.OEXEC:
+		p = plan9.DMEXEC
+	}
+	if mode&plan9.OTRUNC != 0 {
+		p |= plan9.DMWRITE
+	}
+	uid := fid.Uname()
+	if !n.hasPerm(uid, p) || mode&plan9.ORCLOSE != 0 && (n == fsys.root || !n.parent.hasPerm(uid, plan9.DMWRITE)) {
+		return plan9.QID{}, 0, server.ErrPerm
+	}
+	if n.d.Mode&plan9.DMEXCL != 0 && n.opens > 0 {
+		return plan9.QID{}, 0, errExcl
+	}
+	if mode&plan9.OTRUNC != 0 && n.d.Mode&plan9.DMAPPEND == 0 {
+		n.data, n.d.Length = nil, 0
+		n.modified(uid)
+	}
+	n.opens++
+	f.rclose = mode&plan9.ORCLOSE != 0
+	return n.d.QID, 0, nil
+}
+
+func (fsys *FS) Create(ctx context.Context, fid *server.Fid, name string, perm plan9.Perm, mode uint8) (plan9.QID, uint32, error) {
+	if err := server.CheckName(name); err != nil {
+		return plan9.QID{}, 0, err
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	f := aux(fid)
+	dir := f.n
+	uid := fid.Uname()
+	switch {
+	case dir.parent == nil:
+		return plan9.QID{}, 0, errRemoved
+	case !dir.hasPerm(uid, plan9.DMWRITE):
+		return plan9.QID{}, 0, server.ErrPerm
+	case dir.lookup(name) != nil:
+		return plan9.QID{}, 0, errExist
+	}
+	n := fsys.newNode(name, server.CreatePerm(perm, dir.d.Mode), uid, dir.d.GID)
+	dir.link(n)
+	dir.modified(uid)
+	n.opens++
+	f.n, f.rclose = n, mode&plan9.ORCLOSE != 0
+	return n.d.QID, 0, nil
+}
+
+func (fsys *FS) Read(ctx context.Context, fid *server.Fid, offset uint64, p []byte) (int, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	f := aux(fid)
+	n := f.n
+	if n.parent == nil {
+		return 0, errRemoved
+	}
+	n.d.Atime = uint32(time.Now().Unix())
+	if n.isDir() {
+		return f.dirs.Read(offset, p, n.list)
+	}
+	if offset >= uint64(len(n.data)) {
+		return 0, io.EOF
+	}
+	return copy(p, n.data[offset:]), nil
+}
+
+// list returns the entries of the directory n.
+func (n *node) list() ([]plan9.Dir, error) {
+	ents := make([]plan9.Dir, 0, len(n.children))
+	for _, c := range n.children {
+		ents = append(ents, c.d)
+	}
+	return ents, nil
+}
+
+func (fsys *FS) Write(ctx context.Context, fid *server.Fid, offset uint64, data []byte) (int, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	n := aux(fid).n
+	if n.parent == nil {
+		return 0, errRemoved
+	}
+	if n.d.Mode&plan9.DMAPPEND != 0 {
+		offset = uint64(len(n.data))
+	}
+	if offset > maxLength || uint64(len(data)) > maxLength-offset {
+		return 0, errTooBig
+	}
+	if end := offset + uint64(len(data)); end > uint64(len(n.data)) {
+		n.data = append(n.data, make([]byte, int(end)-len(n.data))...)
+	}
+	copy(n.data[offset:], data)
+	n.d.Length = uint64(len(n.data))
+	n.modified(fid.Uname())
+	return len(data), nil
+}
+
+func (fsys *FS) Clunk(ctx context.Context, fid *server.Fid) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	f := aux(fid)
+	if _, open := fid.Mode(); open {
+		f.n.opens--
+	}
+	if f.rclose && f.n.parent != nil {
+		return fsys.remove(f.n, fid.Uname())
+	}
+	return nil
+}
+
+func (fsys *FS) Remove(ctx context.Context, fid *server.Fid) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	f := aux(fid)
+	if _, open := fid.Mode(); open {
+		f.n.opens--
+	}
+	if f.n.parent == nil {
+		return errRemoved
+	}
+	return fsys.remove(f.n, fid.Uname())
+}
+
+// remove removes n as uid.
+func (fsys *FS) remove(n *node, uid string) error {
+	switch {
+	case n == fsys.root || !n.parent.hasPerm(uid, plan9.DMWRITE):
+		return server.ErrPerm
+	case len(n.children) > 0:
+		return errNotEmpty
+	}
+	n.parent.modified(uid)
+	n.parent.unlink(n)
+	return nil
+}
+
+func (fsys *FS) Stat(ctx context.Context, fid *server.Fid) (*plan9.Dir, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+	n := aux(fid).n
+	if n.parent == nil {
+		return nil, errRemoved
+	}
+	d := n.d
+	return &d, nil
+}
+
+// Wstat changes the name, mode, length, modification time and group of a
+// file, as permitted by stat(5). Its owner cannot be changed.
+func (fsys *FS) Wstat(ctx context.Context, fid *server.Fid, d *plan9.Dir) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	n := aux(fid).n
+	if n.parent == nil {
+		return errRemoved
+	}
+	uid := fid.Uname()
+	owner := uid == n.d.UID
+	switch {
+	case d.Type != ^uint16(0) || d.Dev != ^uint32(0) || d.UID != "" && d.UID != n.d.UID || d.Muid != "":
+		return server.ErrPerm
+	case d.Mode != ^plan9.Perm(0) && (!owner || d.Mode&plan9.DMDIR != n.d.Mode&plan9.DMDIR):
+		return server.ErrPerm
+	case d.Mtime != ^uint32(0) && !owner:
+		return server.ErrPerm
+	case d.GID != "" && !owner:
+		return server.ErrPerm
+	case d.Length != ^uint64(0) && (n.isDir() && d.Length != 0 || !n.hasPerm(uid, plan9.DMWRITE)):
+		return server.ErrPerm
+	case d.Length != ^uint64(0) && d.Length > maxLength:
+		return errTooBig
+	}
+	if d.Name != "" && d.Name != n.d.Name {
+		switch {
+		case n == fsys.root:
+			return server.ErrBadName
+		case server.CheckName(d.Name) != nil:
+			return server.ErrBadName
+		case !n.parent.hasPerm(uid, plan9.DMWRITE):
+			return server.ErrPerm
+		case n.parent.lookup(d.Name) != nil:
+			return errExist
+		}
+		dir := n.parent
+		dir.unlink(n)
+		n.d.Name = d.Name
+		dir.link(n)
+	}
+	if d.Mode != ^plan9.Perm(0) {
+		n.d.Mode = d.Mode
+		n.d.QID.Type = d.Mode.QIDType()
+	}
+	if d.Length != ^uint64(0) && !n.isDir() {
+		if d.Length < uint64(len(n.data)) {
+			n.data = n.data[:d.Length]
+		} else {
+			n.data = append(n.data, make([]byte, int(d.Length)-len(n.data))...)
+		}
+		n.d.Length = d.Length
+		n.modified(uid)
+	}
+	if d.Mtime != ^uint32(0) {
+		n.d.Mtime = d.Mtime
+	}
+	if d.GID != "" {
+		n.d.GID = d.GID
+	}
+	return nil
+}
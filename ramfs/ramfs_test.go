@@ -0,0 +1,304 @@
+package ramfs
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	"plan9.io/client"
+	proto "plan9.io/encoding/plan9"
+	"plan9.io/server"
+)
+
+// testRoot serves fsys on one end of a pipe and attaches to it as uname
+// from the other.
+func testRoot(t *testing.T, fsys *FS, uname string) *client.File {
+	c, s := net.Pipe()
+	go (&server.Server{Handler: fsys, Msize: 8192}).ServeConn(s)
+	conn, err := client.NewConn(context.Background(), c, plan9.MSize, plan9.DefaultVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	root, err := conn.Root(context.Background(), uname, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestFS(t *testing.T) {
+	fsys := New("glenda")
+	files := map[string]string{
+		"lib/profile":  "bind -a $home/bin/$cputype /bin\n",
+		"lib/plumbing": "include basic\n",
+		"bin/rc/g":     "#!/bin/rc\n",
+		"tmp/empty":    "",
+	}
+	for name, content := range files {
+		assert.NilError(t, fsys.WriteFile(name, []byte(content), 0644))
+	}
+	root := testRoot(t, fsys, "glenda")
+	cfs := client.NewFS(root)
+	if err := fstest.TestFS(cfs, "lib/profile", "lib/plumbing", "bin/rc/g", "tmp/empty"); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := cfs.Stat("lib/profile")
+	assert.NilError(t, err)
+	d := fi.Sys().(*plan9.Dir)
+	assert.Equal(t, d.UID, "glenda")
+	assert.Equal(t, d.Mode, plan9.Perm(0644))
+	assert.NilError(t, d.Check())
+}
+
+func TestWriteFile(t *testing.T) {
+	ctx := context.Background()
+	fsys := New("glenda")
+	tests := []struct {
+		name string
+		want error
+		walk []string // the walk reaching the file, if it is created
+	}{
+		{"/etc/motd", nil, []string{"etc", "motd"}},
+		{"usr//glenda/./lib/profile", nil, []string{"usr", "glenda", "lib", "profile"}},
+		{"/", server.ErrBadName, nil},
+		{"", server.ErrBadName, nil},
+		{".", server.ErrBadName, nil},
+		{"..", server.ErrBadName, nil},
+		{"../x", server.ErrBadName, nil},
+		{"/../x", nil, []string{"x"}}, // .. of the root is the root
+	}
+	root := testRoot(t, fsys, "glenda")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, fsys.WriteFile(tt.name, []byte("x"), 0644), tt.want)
+			if tt.walk != nil {
+				f, err := root.Walk(ctx, tt.walk...)
+				assert.NilError(t, err)
+				f.Close()
+			}
+		})
+	}
+	// nothing unreachable was created
+	dir, err := root.Walk(ctx)
+	assert.NilError(t, err)
+	assert.NilError(t, dir.Open(ctx, plan9.OREAD))
+	b, err := ioutil.ReadAll(dir)
+	assert.NilError(t, err)
+	var names []string
+	for len(b) > 0 {
+		d, rest, err := proto.UnmarshalDir(b, plan9.Dialect9P2000)
+		assert.NilError(t, err)
+		names = append(names, d.Name)
+		b = rest
+	}
+	assert.DeepEqual(t, names, []string{"etc", "usr", "x"})
+}
+
+func TestFiles(t *testing.T) {
+	ctx := context.Background()
+	fsys := New("glenda")
+	assert.NilError(t, fsys.WriteFile("tmp/x", []byte("x"), 0644))
+	root := testRoot(t, fsys, "glenda")
+
+	dir, err := root.Walk(ctx, "tmp")
+	assert.NilError(t, err)
+	f, err := dir.Walk(ctx)
+	assert.NilError(t, err)
+	assert.NilError(t, f.Create(ctx, "log", plan9.DMAPPEND|0666, plan9.ORDWR))
+	for _, s := range []string{"one ", "two"} {
+		_, err = f.WriteAt([]byte(s), 0)
+		assert.NilError(t, err)
+	}
+	b, err := ioutil.ReadAll(f)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "one two")
+	d, err := f.Stat(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Mode, plan9.DMAPPEND|0666)
+	assert.Equal(t, d.Muid, "glenda")
+	assert.Equal(t, d.QID.Vers, uint32(2))
+	assert.NilError(t, f.Close())
+
+	tests := []struct {
+		name string
+		do   func() error
+		want string // the error, or ""
+	}{
+		{"create existing", func() error {
+			f, _ := dir.Walk(ctx)
+			defer f.Close()
+			return f.Create(ctx, "x", 0644, plan9.OREAD)
+		}, "file exists"},
+		{"remove directory", func() error {
+			f, _ := root.Walk(ctx, "tmp")
+			return f.Remove(ctx)
+		}, "directory not empty"},
+		{"remove root", func() error {
+			f, _ := root.Walk(ctx)
+			return f.Remove(ctx)
+		}, "permission denied"},
+		{"exclusive use", func() error {
+			f, _ := dir.Walk(ctx)
+			defer f.Close()
+			if err := f.Create(ctx, "lock", plan9.DMEXCL|0666, plan9.OREAD); err != nil {
+				return err
+			}
+			g, _ := dir.Walk(ctx, "lock")
+			defer g.Close()
+			return g.Open(ctx, plan9.OREAD)
+		}, "exclusive use file already open"},
+		{"rename", func() error {
+			f, _ := dir.Walk(ctx, "x")
+			defer f.Close()
+			nd := plan9.NullDir().WithName("y")
+			if err := f.Wstat(ctx, &nd); err != nil {
+				return err
+			}
+			_, err := dir.Walk(ctx, "y")
+			return err
+		}, ""},
+		{"rename to existing", func() error {
+			f, _ := dir.Walk(ctx, "y")
+			defer f.Close()
+			nd := plan9.NullDir().WithName("log")
+			return f.Wstat(ctx, &nd)
+		}, "file exists"},
+		{"truncate", func() error {
+			f, _ := dir.Walk(ctx, "y")
+			defer f.Close()
+			if err := f.Open(ctx, plan9.OWRITE|plan9.OTRUNC); err != nil {
+				return err
+			}
+			d, err := f.Stat(ctx)
+			if err == nil && d.Length != 0 {
+				t.Errorf("length %d after OTRUNC", d.Length)
+			}
+			return err
+		}, ""},
+		{"write past the largest file", func() error {
+			f, _ := dir.Walk(ctx, "y")
+			defer f.Close()
+			if err := f.Open(ctx, plan9.OWRITE); err != nil {
+				return err
+			}
+			_, err := f.WriteAt([]byte("x"), 1<<62)
+			return err
+		}, "file too large"},
+		{"grow past the largest file", func() error {
+			f, _ := dir.Walk(ctx, "y")
+			defer f.Close()
+			nd := plan9.NullDir().WithLength(1 << 62)
+			return f.Wstat(ctx, &nd)
+		}, "file too large"},
+		{"remove on close", func() error {
+			f, _ := dir.Walk(ctx, "y")
+			if err := f.Open(ctx, plan9.OREAD|plan9.ORCLOSE); err != nil {
+				return err
+			}
+			f.Close()
+			_, err := dir.Walk(ctx, "y")
+			return err
+		}, "file does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.do()
+			if tt.want == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.DeepEqual(t, err, &client.Error{Ename: tt.want})
+			}
+		})
+	}
+}
+
+func TestPerm(t *testing.T) {
+	ctx := context.Background()
+	fsys := New("glenda")
+	assert.NilError(t, fsys.WriteFile("usr/glenda/private", []byte("secret"), 0600))
+	assert.NilError(t, fsys.WriteFile("usr/glenda/public", []byte("hello"), 0644))
+	root := testRoot(t, fsys, "glenda")
+	home, err := root.Walk(ctx, "usr", "glenda")
+	assert.NilError(t, err)
+	nd := plan9.NullDir().WithMode(plan9.DMDIR | 0755)
+	assert.NilError(t, home.Wstat(ctx, &nd))
+
+	other := testRoot(t, fsys, "bootes")
+	tests := []struct {
+		name string
+		root *client.File // the attach of the user
+		path []string
+		do   func(f *client.File) error
+		want string
+	}{
+		{"read own file", root, []string{"private"}, func(f *client.File) error { return f.Open(ctx, plan9.OREAD) }, ""},
+		{"read other's file", other, []string{"private"}, func(f *client.File) error { return f.Open(ctx, plan9.OREAD) }, "permission denied"},
+		{"read public file", other, []string{"public"}, func(f *client.File) error { return f.Open(ctx, plan9.OREAD) }, ""},
+		{"write public file", other, []string{"public"}, func(f *client.File) error { return f.Open(ctx, plan9.OWRITE) }, "permission denied"},
+		{"truncate public file", other, []string{"public"}, func(f *client.File) error { return f.Open(ctx, plan9.OREAD|plan9.OTRUNC) }, "permission denied"},
+		{"create in other's directory", other, nil, func(f *client.File) error { return f.Create(ctx, "x", 0644, plan9.OREAD) }, "permission denied"},
+		{"remove from other's directory", other, []string{"public"}, func(f *client.File) error { return f.Remove(ctx) }, "permission denied"},
+		{"chmod other's file", other, []string{"public"}, func(f *client.File) error {
+			nd := plan9.NullDir().WithMode(0666)
+			return f.Wstat(ctx, &nd)
+		}, "permission denied"},
+		{"chown", other, []string{"public"}, func(f *client.File) error {
+			nd := plan9.NullDir()
+			nd.UID = "bootes"
+			return f.Wstat(ctx, &nd)
+		}, "permission denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.root.Walk(ctx, append([]string{"usr", "glenda"}, tt.path...)...)
+			assert.NilError(t, err)
+			defer f.Close()
+			err = tt.do(f)
+			if tt.want == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.DeepEqual(t, err, &client.Error{Ename: tt.want})
+			}
+		})
+	}
+}
+
+func TestWalkSelf(t *testing.T) {
+	ctx := context.Background()
+	fsys := New("glenda")
+	assert.NilError(t, fsys.WriteFile(strings.Repeat("a/", 100)+"x", nil, 0644))
+	c, s := net.Pipe()
+	go (&server.Server{Handler: fsys, Msize: 8192}).ServeConn(s)
+	conn, err := client.NewConn(ctx, c, plan9.MSize, plan9.DefaultVersion)
+	assert.NilError(t, err)
+	defer conn.Close()
+	_, err = conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.NilError(t, err)
+
+	// a fid walked to itself while it is stat'd
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := conn.Walk(ctx, 1, 1, "a")
+			assert.NilError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := conn.Stat(ctx, 1)
+			assert.NilError(t, err)
+		}()
+	}
+	wg.Wait()
+	d, err := conn.Stat(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Name, "a")
+}
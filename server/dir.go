@@ -0,0 +1,69 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+// Errors for Handlers serving a tree of files.
+var (
+	ErrBadName = errors.New("bad file name")
+	ErrDirBig  = errors.New("directory entry larger than read count")
+)
+
+// CheckName returns ErrBadName unless name can name a file in a directory:
+// it is not "", "." or ".." and has no slash.
+func CheckName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return ErrBadName
+	}
+	return nil
+}
+
+// CreatePerm returns the permissions of a file created with perm in a
+// directory with the permissions dirperm, which limit them as in open(5).
+func CreatePerm(perm, dirperm plan9.Perm) plan9.Perm {
+	if perm&plan9.DMDIR != 0 {
+		return perm & (^plan9.Perm(0777) | dirperm&0777)
+	}
+	return perm & (^plan9.Perm(0666) | dirperm&0666)
+}
+
+// A DirList holds the entries of an open directory that are yet to be
+// read. The zero DirList is empty.
+type DirList struct {
+	ents []plan9.Dir
+}
+
+// Read answers a read of the directory at offset with the whole 9P2000
+// stat records that fit in p, as read(5) requires. A read at offset 0
+// starts over with the entries list returns; others continue after the
+// last entry read.
+func (l *DirList) Read(offset uint64, p []byte, list func() ([]plan9.Dir, error)) (int, error) {
+	if offset == 0 {
+		ents, err := list()
+		if err != nil {
+			return 0, err
+		}
+		l.ents = ents
+	}
+	var b []byte
+	for len(l.ents) > 0 {
+		next, err := proto.MarshalDir(b, &l.ents[0], plan9.Dialect9P2000)
+		if err != nil {
+			return 0, err
+		}
+		if len(next) > len(p) {
+			if len(b) == 0 {
+				return 0, ErrDirBig
+			}
+			break
+		}
+		b = next
+		l.ents = l.ents[1:]
+	}
+	return copy(p, b), nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+func TestCheckName(t *testing.T) {
+	for _, name := range []string{"x", "...", ".profile"} {
+		assert.NilError(t, CheckName(name))
+	}
+	for _, name := range []string{"", ".", "..", "a/b", "/"} {
+		assert.Equal(t, CheckName(name), ErrBadName)
+	}
+}
+
+func TestCreatePerm(t *testing.T) {
+	tests := []struct {
+		perm, dirperm, want plan9.Perm
+	}{
+		{0666, 0755, 0644},
+		{0777, 0750, 0751}, // execute bits are not limited
+		{plan9.DMDIR | 0777, plan9.DMDIR | 0750, plan9.DMDIR | 0750},
+		{plan9.DMAPPEND | 0622, 0777, plan9.DMAPPEND | 0622},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, CreatePerm(tt.perm, tt.dirperm), tt.want)
+	}
+}
+
+func TestDirList(t *testing.T) {
+	ents := []plan9.Dir{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	list := func() ([]plan9.Dir, error) { return append([]plan9.Dir(nil), ents...), nil }
+	size := ents[0].WireSize()
+
+	var l DirList
+	p := make([]byte, 2*size+1)
+	var names []string
+	read := func(offset uint64) int {
+		n, err := l.Read(offset, p, list)
+		assert.NilError(t, err)
+		dirs, err := proto.UnmarshalDirs(p[:n])
+		assert.NilError(t, err)
+		for _, d := range dirs {
+			names = append(names, d.Name)
+		}
+		return n
+	}
+	// whole records only, continuing after the last one read
+	assert.Equal(t, read(0), 2*size)
+	assert.Equal(t, read(uint64(2*size)), size)
+	assert.Equal(t, read(uint64(3*size)), 0)
+	// offset 0 starts over
+	read(0)
+	assert.DeepEqual(t, names, []string{"a", "b", "c", "a", "b"})
+
+	_, err := l.Read(0, p[:size-1], list)
+	assert.Equal(t, err, ErrDirBig)
+}
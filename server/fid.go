@@ -0,0 +1,291 @@
+package server
+
+import (
+	"errors"
+	"sync"
+
+	"plan9.io"
+)
+
+// Errors sent for requests that are invalid in the state of their fid,
+// worded as by Plan 9's lib9p.
+var (
+	ErrUnknownFid   = errors.New("unknown fid")
+	ErrDupFid       = errors.New("duplicate fid")
+	ErrBotch        = errors.New("9P protocol botch")
+	ErrCloneOpen    = errors.New("cannot clone open fid")
+	ErrWalkNoDir    = errors.New("walk in non-directory")
+	ErrCreateNonDir = errors.New("create in non-directory")
+	ErrIsDir        = errors.New("is a directory")
+	ErrPerm         = errors.New("permission denied")
+	ErrBadOffset    = errors.New("bad offset")
+)
+
+// maxWelem is the most names a Twalk may carry.
+const maxWelem = 16
+
+var errTooManyNames = errors.New("too many names in walk")
+
+// Fid is a fid of a connection and the state the protocol gives it.
+type Fid struct {
+	fid    plan9.FID
+	refs   int           // the table's and those of requests, guarded by the FidTable
+	idle   chan struct{} // closed once refs drops to 0
+	hidden bool          // added but not yet published, guarded by the FidTable
+
+	mu     sync.Mutex // guards the fields below
+	qid    plan9.QID
+	uname  string
+	open   bool
+	mode   uint8
+	offset uint64 // where the next directory read must start
+
+	// Aux is for the Handler, e.g. to hold the file the fid represents.
+	Aux interface{}
+}
+
+// FID returns the number the client knows the fid by.
+func (f *Fid) FID() plan9.FID { return f.fid }
+
+// QID returns the qid of the file the fid represents.
+func (f *Fid) QID() plan9.QID {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.qid
+}
+
+// Uname returns the user the fid was attached by.
+func (f *Fid) Uname() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.uname
+}
+
+// Mode returns the mode the fid was opened with, and whether it is open.
+func (f *Fid) Mode() (mode uint8, open bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mode, f.open
+}
+
+// DirOffset returns the offset the next read of an open directory must
+// start at, unless it starts over at 0.
+func (f *Fid) DirOffset() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.offset
+}
+
+// attached records that f was attached to qid by uname.
+func (f *Fid) attached(qid plan9.QID, uname string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.qid, f.uname = qid, uname
+}
+
+// checkWalk checks that f can be walked through names.
+func (f *Fid) checkWalk(names []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(names) > maxWelem {
+		return errTooManyNames
+	}
+	if f.open {
+		return ErrCloneOpen
+	}
+	if len(names) > 0 && !f.qid.IsDir() {
+		return ErrWalkNoDir
+	}
+	return nil
+}
+
+// walked sets the state of newfid, walked from f to qid.
+func (f *Fid) walked(newfid *Fid, qid plan9.QID) {
+	f.mu.Lock()
+	uname := f.uname
+	f.mu.Unlock()
+	newfid.mu.Lock()
+	defer newfid.mu.Unlock()
+	newfid.qid, newfid.uname = qid, uname
+}
+
+// checkOpen checks that f can be opened with mode.
+func (f *Fid) checkOpen(mode uint8) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.open {
+		return ErrBotch
+	}
+	if f.qid.IsDir() && mode&^plan9.ORCLOSE != plan9.OREAD {
+		return ErrIsDir
+	}
+	return nil
+}
+
+// checkCreate checks that a file can be created in f.
+func (f *Fid) checkCreate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.open {
+		return ErrBotch
+	}
+	if !f.qid.IsDir() {
+		return ErrCreateNonDir
+	}
+	return nil
+}
+
+// opened records that f is open with mode on the file qid.
+func (f *Fid) opened(qid plan9.QID, mode uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.qid, f.open, f.mode, f.offset = qid, true, mode, 0
+}
+
+// checkRead checks that f can be read at offset.
+func (f *Fid) checkRead(offset uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return ErrBotch
+	}
+	if o := f.mode & 3; o != plan9.OREAD && o != plan9.ORDWR && o != plan9.OEXEC {
+		return ErrPerm
+	}
+	if f.qid.IsDir() && offset != 0 && offset != f.offset {
+		return ErrBadOffset
+	}
+	return nil
+}
+
+// read records a read of n bytes at offset.
+func (f *Fid) read(offset uint64, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.qid.IsDir() {
+		f.offset = offset + uint64(n)
+	}
+}
+
+// checkWrite checks that f can be written.
+func (f *Fid) checkWrite() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return ErrBotch
+	}
+	if o := f.mode & 3; o != plan9.OWRITE && o != plan9.ORDWR {
+		return ErrPerm
+	}
+	return nil
+}
+
+// FidTable holds the fids of a connection.
+type FidTable struct {
+	mu   sync.Mutex
+	fids map[plan9.FID]*Fid
+}
+
+// NewFidTable returns an empty FidTable.
+func NewFidTable() *FidTable {
+	return &FidTable{fids: make(map[plan9.FID]*Fid)}
+}
+
+// Like the fids of lib9p, a Fid is counted by the table holding it and by
+// each request using it, so that it is not clunked under a request.
+
+// Get returns the fid numbered fid, with a reference the caller must Put.
+func (t *FidTable) Get(fid plan9.FID) (*Fid, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, ok := t.fids[fid]
+	if !ok || f.hidden {
+		return nil, ErrUnknownFid
+	}
+	f.refs++
+	return f, nil
+}
+
+// Add reserves the number fid for a new fid, with a reference the caller
+// must Put. Get does not find the fid until it is published, once the
+// Tattach or Twalk creating it has succeeded; on failure it is Del'd.
+func (t *FidTable) Add(fid plan9.FID) (*Fid, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.fids[fid]; ok || fid == plan9.NoFID {
+		return nil, ErrDupFid
+	}
+	f := &Fid{fid: fid, refs: 2, idle: make(chan struct{}), hidden: true}
+	t.fids[fid] = f
+	return f, nil
+}
+
+// Publish makes f, returned by Add, found by Get.
+func (t *FidTable) Publish(f *Fid) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f.hidden = false
+}
+
+// Put drops a reference to f.
+func (t *FidTable) Put(f *Fid) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.put(f)
+}
+
+func (t *FidTable) put(f *Fid) {
+	f.refs--
+	if f.refs == 0 {
+		close(f.idle)
+	}
+}
+
+// Del removes the fid numbered fid.
+func (t *FidTable) Del(fid plan9.FID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if f, ok := t.fids[fid]; ok {
+		delete(t.fids, fid)
+		t.put(f)
+	}
+}
+
+// Release removes f, to which the caller holds a reference, and drops that
+// reference. It then waits until no other request holds f, so that f can
+// be clunked. It returns ErrUnknownFid if f has already been removed.
+func (t *FidTable) Release(f *Fid) error {
+	t.mu.Lock()
+	removed := t.fids[f.fid] != f
+	if !removed {
+		delete(t.fids, f.fid)
+		t.put(f)
+	}
+	t.put(f)
+	t.mu.Unlock()
+	if removed {
+		return ErrUnknownFid
+	}
+	<-f.idle
+	return nil
+}
+
+// Clear removes every fid, returning them.
+func (t *FidTable) Clear() []*Fid {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fids := make([]*Fid, 0, len(t.fids))
+	for _, f := range t.fids {
+		fids = append(fids, f)
+		t.put(f)
+	}
+	t.fids = make(map[plan9.FID]*Fid)
+	return fids
+}
+
+// Len returns the number of fids in the table.
+func (t *FidTable) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.fids)
+}
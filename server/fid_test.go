@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	"plan9.io/client"
+)
+
+func TestFidState(t *testing.T) {
+	ctx := context.Background()
+	fs := &flatFS{files: map[string][]byte{"hello": []byte("hello")}}
+	conn := testConn(t, fs)
+	defer conn.Close()
+
+	// fid 1 is the root, 2 an open file, 3 an unopened file and 4 an open directory
+	_, err := conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.NilError(t, err)
+	for _, fid := range []plan9.FID{2, 3} {
+		_, err = conn.Walk(ctx, 1, fid, "hello")
+		assert.NilError(t, err)
+	}
+	_, _, err = conn.Open(ctx, 2, plan9.OWRITE)
+	assert.NilError(t, err)
+	_, err = conn.Walk(ctx, 1, 4)
+	assert.NilError(t, err)
+	_, _, err = conn.Open(ctx, 4, plan9.OREAD)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name string
+		do   func() error
+		want error
+	}{
+		{"walk unknown fid", func() error { _, err := conn.Walk(ctx, 9, 10); return err }, ErrUnknownFid},
+		{"walk to live fid", func() error { _, err := conn.Walk(ctx, 1, 3); return err }, ErrDupFid},
+		{"walk open fid", func() error { _, err := conn.Walk(ctx, 2, 10); return err }, ErrCloneOpen},
+		{"walk in file", func() error { _, err := conn.Walk(ctx, 3, 10, "x"); return err }, ErrWalkNoDir},
+		{"attach live fid", func() error { _, err := conn.Attach(ctx, 3, plan9.NoFID, "glenda", ""); return err }, ErrDupFid},
+		{"attach unknown afid", func() error { _, err := conn.Attach(ctx, 10, 9, "glenda", ""); return err }, ErrUnknownFid},
+		{"open twice", func() error { _, _, err := conn.Open(ctx, 2, plan9.OREAD); return err }, ErrBotch},
+		{"open directory for writing", func() error { _, _, err := conn.Open(ctx, 1, plan9.OWRITE); return err }, ErrIsDir},
+		{"create in file", func() error { _, _, err := conn.Create(ctx, 3, "x", 0644, plan9.OREAD); return err }, ErrCreateNonDir},
+		{"read unopened", func() error { _, err := conn.Read(ctx, 3, 0, 10); return err }, ErrBotch},
+		{"read write-only", func() error { _, err := conn.Read(ctx, 2, 0, 10); return err }, ErrPerm},
+		{"write unopened", func() error { _, err := conn.Write(ctx, 3, 0, nil); return err }, ErrBotch},
+		{"write directory", func() error { _, err := conn.Write(ctx, 4, 0, nil); return err }, ErrPerm},
+		{"seek in directory", func() error { _, err := conn.Read(ctx, 4, 10, 10); return err }, ErrBadOffset},
+		{"clunk unknown fid", func() error { return conn.Clunk(ctx, 9) }, ErrUnknownFid},
+		{"stat unknown fid", func() error { _, err := conn.Stat(ctx, 9); return err }, ErrUnknownFid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, tt.do(), &client.Error{Ename: tt.want.Error()})
+		})
+	}
+
+	// directory reads continue where the last one ended
+	_, err = conn.Read(ctx, 4, 0, 10)
+	assert.NilError(t, err)
+	_, err = conn.Read(ctx, 4, 0, 10)
+	assert.NilError(t, err)
+}
+
+func TestFidTable(t *testing.T) {
+	ft := NewFidTable()
+	f, err := ft.Add(1)
+	assert.NilError(t, err)
+	assert.Equal(t, f.FID(), plan9.FID(1))
+	_, err = ft.Add(1)
+	assert.Equal(t, err, ErrDupFid)
+	_, err = ft.Add(plan9.NoFID)
+	assert.Equal(t, err, ErrDupFid)
+	_, err = ft.Get(1)
+	assert.Equal(t, err, ErrUnknownFid)
+	ft.Publish(f)
+	g, err := ft.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, g, f)
+	_, err = ft.Get(2)
+	assert.Equal(t, err, ErrUnknownFid)
+
+	ft.Add(2)
+	assert.Equal(t, len(ft.Clear()), 2)
+	assert.Equal(t, ft.Len(), 0)
+	ft.Add(3)
+	ft.Del(3)
+	assert.Equal(t, ft.Len(), 0)
+}
@@ -0,0 +1,498 @@
+// Package server implements a 9P file server.
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"plan9.io"
+	proto "plan9.io/encoding/plan9"
+)
+
+// Handler implements the file system served on a connection. Its methods
+// are called from a goroutine per request, so requests on different fids
+// run concurrently. An error is sent to the client as an Rerror.
+type Handler interface {
+	// Attach attaches fid to the root of the file tree aname for uname.
+	// afid is the authentication fid, or nil.
+	Attach(ctx context.Context, fid, afid *Fid, uname, aname string) (plan9.QID, error)
+	// Walk walks newfid from fid through names, returning the qids of
+	// the names walked. If fewer than names are walked, newfid is
+	// discarded; if not even the first is, Walk returns an error.
+	// newfid is fid if the client walks a fid to itself.
+	Walk(ctx context.Context, fid, newfid *Fid, names []string) ([]plan9.QID, error)
+	// Open opens fid with mode, returning its qid and iounit, or 0 for
+	// an iounit of msize-IOHDRSZ.
+	Open(ctx context.Context, fid *Fid, mode uint8) (plan9.QID, uint32, error)
+	// Create creates name in the directory fid and opens it with mode,
+	// after which fid represents the new file.
+	Create(ctx context.Context, fid *Fid, name string, perm plan9.Perm, mode uint8) (plan9.QID, uint32, error)
+	// Read reads into p at offset. io.EOF is not an error.
+	Read(ctx context.Context, fid *Fid, offset uint64, p []byte) (int, error)
+	// Write writes data at offset.
+	Write(ctx context.Context, fid *Fid, offset uint64, data []byte) (int, error)
+	// Clunk forgets fid, once no other request is using it.
+	Clunk(ctx context.Context, fid *Fid) error
+	// Remove removes the file of fid, once no other request is using
+	// it. fid is forgotten even on error.
+	Remove(ctx context.Context, fid *Fid) error
+	// Stat returns the directory entry of fid.
+	Stat(ctx context.Context, fid *Fid) (*plan9.Dir, error)
+	// Wstat changes the directory entry of fid.
+	Wstat(ctx context.Context, fid *Fid, d *plan9.Dir) error
+}
+
+// Auther is implemented by Handlers that require authentication.
+type Auther interface {
+	// Auth prepares afid for the authentication protocol of uname
+	// attaching to aname, returning its qid.
+	Auth(ctx context.Context, afid *Fid, uname, aname string) (plan9.QID, error)
+}
+
+// Server serves a Handler.
+type Server struct {
+	Handler Handler
+	// Msize is the largest message size the server accepts, or
+	// plan9.MSize if 0.
+	Msize uint32
+}
+
+// Serve serves h on the connections accepted from l.
+func Serve(l net.Listener, h Handler) error {
+	return (&Server{Handler: h}).Serve(l)
+}
+
+// Serve serves the connections accepted from l, each in its own goroutine.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(c)
+	}
+}
+
+var errNoVersion = errors.New("9p: first message not Tversion")
+
+// ServeConn serves a single connection, closing it when the client hangs
+// up or sends a malformed message.
+func (s *Server) ServeConn(rwc io.ReadWriteCloser) error {
+	defer rwc.Close()
+	c := &conn{
+		srv:  s,
+		dec:  proto.NewDecoder(rwc),
+		enc:  proto.NewEncoder(rwc),
+		fids: NewFidTable(),
+		reqs: make(map[plan9.Tag]*request),
+	}
+	defer c.reset()
+	versioned := false
+	for {
+		msg, err := c.dec.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if v, ok := msg.(*proto.VersionReq); ok {
+			if err := c.version(v); err != nil {
+				return err
+			}
+			versioned = c.session.Version != plan9.VersionUnknown
+			continue
+		}
+		if !versioned {
+			return errNoVersion
+		}
+		tag := msg.Header().Tag()
+		_, isFlush := msg.(*proto.FlushReq)
+		r, err := c.start(tag, isFlush)
+		if err != nil {
+			c.send(tag, func(enc *proto.Encoder) error { return enc.Rerror(tag, err.Error(), 0) })
+			continue
+		}
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.serve(r, msg)
+		}()
+	}
+}
+
+// conn is the state of a connection.
+type conn struct {
+	srv     *Server
+	dec     *proto.Decoder
+	session plan9.Session
+	wg      sync.WaitGroup // outstanding requests
+
+	wmu sync.Mutex // guards enc
+	enc *proto.Encoder
+
+	fids *FidTable
+
+	mu   sync.Mutex // guards reqs
+	reqs map[plan9.Tag]*request
+}
+
+// request is an outstanding request.
+type request struct {
+	tag    plan9.Tag
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the request has replied or been abandoned
+	flush  bool          // a Tflush, which is not itself flushed
+}
+
+var errDupTag = errors.New("duplicate tag")
+
+// start registers a request with tag, a Tflush if flush is set.
+func (c *conn) start(tag plan9.Tag, flush bool) (*request, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.reqs[tag]; ok {
+		return nil, errDupTag
+	}
+	r := &request{tag: tag, done: make(chan struct{}), flush: flush}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	c.reqs[tag] = r
+	return r, nil
+}
+
+// finish forgets r, so that the client may reuse its tag.
+func (c *conn) finish(r *request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reqs[r.tag] == r {
+		delete(c.reqs, r.tag)
+	}
+}
+
+// flush cancels the request with oldtag and waits until it is done, as
+// flush(5) requires the reply to it to precede the Rflush, or until ctx,
+// that of the Tflush, is done. Tflushes are answered at once rather than
+// cancelled, so that two flushing each other do not wait forever.
+func (c *conn) flush(ctx context.Context, tag, oldtag plan9.Tag) {
+	c.mu.Lock()
+	r, ok := c.reqs[oldtag]
+	c.mu.Unlock()
+	if !ok || oldtag == tag || r.flush {
+		return
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+	}
+}
+
+// version starts a new session, after the outstanding requests of the
+// current one are done and its fids are clunked.
+func (c *conn) version(v *proto.VersionReq) error {
+	c.reset()
+	msize := c.srv.Msize
+	if msize == 0 {
+		msize = plan9.MSize
+	}
+	session, err := plan9.Negotiate(v.Msize(), v.Version(), msize)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err != nil {
+		// no session until a Tversion the server accepts
+		c.session = plan9.Session{Version: plan9.VersionUnknown}
+		if err := c.enc.Rerror(v.Header().Tag(), err.Error(), 0); err != nil {
+			return err
+		}
+		return c.enc.Flush()
+	}
+	c.session = session
+	if err := c.enc.Rversion(v.Header().Tag(), c.session.Msize, c.session.Version); err != nil {
+		return err
+	}
+	if c.session.Version != plan9.VersionUnknown {
+		c.dec.SetSession(c.session)
+		c.enc.SetSession(c.session)
+	}
+	return c.enc.Flush()
+}
+
+// reset cancels the outstanding requests, waits for them and clunks
+// every fid.
+func (c *conn) reset() {
+	c.mu.Lock()
+	for _, r := range c.reqs {
+		r.cancel()
+	}
+	c.mu.Unlock()
+	c.wg.Wait()
+	for _, f := range c.fids.Clear() {
+		c.srv.Handler.Clunk(context.Background(), f)
+	}
+}
+
+var (
+	errNoAuth   = errors.New("authentication not required")
+	errBadMsg   = errors.New("unexpected message")
+	errNotFound = errors.New("file does not exist")
+)
+
+// reply writes the R-message answering r with write. r is forgotten with
+// wmu held, so that a Tflush that no longer finds r is answered after it.
+func (c *conn) reply(r *request, write func(enc *proto.Encoder) error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.finish(r)
+	c.write(r.tag, write)
+}
+
+// send writes the R-message answering tag with write.
+func (c *conn) send(tag plan9.Tag, write func(enc *proto.Encoder) error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.write(tag, write)
+}
+
+// write writes the R-message answering tag with write, with wmu held. A
+// reply that cannot be encoded, such as an Rstat larger than the msize, is
+// replaced by an Rerror.
+func (c *conn) write(tag plan9.Tag, write func(enc *proto.Encoder) error) {
+	if err := write(c.enc); err != nil {
+		c.enc.Rerror(tag, err.Error(), 0)
+	}
+	c.enc.Flush()
+}
+
+// serve answers a request. The errors of flushed requests are not sent.
+func (c *conn) serve(r *request, msg proto.Message) {
+	tag := msg.Header().Tag()
+	defer close(r.done)
+	defer r.cancel()
+	err := c.handle(r, msg)
+	if err != nil && r.ctx.Err() == nil {
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rerror(tag, err.Error(), 0) })
+	}
+	c.finish(r)
+}
+
+// handle calls the Handler for msg and replies if it succeeds.
+func (c *conn) handle(r *request, msg proto.Message) error {
+	h, ctx := c.srv.Handler, r.ctx
+	tag := r.tag
+	switch m := msg.(type) {
+	case *proto.AuthReq:
+		a, ok := h.(Auther)
+		if !ok {
+			return errNoAuth
+		}
+		afid, err := c.fids.Add(m.Afid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(afid)
+		qid, err := a.Auth(ctx, afid, m.Uname(), m.Aname())
+		if err != nil {
+			c.fids.Del(m.Afid())
+			return err
+		}
+		afid.attached(qid, m.Uname())
+		afid.opened(qid, plan9.ORDWR)
+		c.fids.Publish(afid)
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rauth(tag, qid) })
+
+	case *proto.AttachReq:
+		var afid *Fid
+		if m.Afid() != plan9.NoFID {
+			var err error
+			if afid, err = c.fids.Get(m.Afid()); err != nil {
+				return err
+			}
+			defer c.fids.Put(afid)
+		}
+		fid, err := c.fids.Add(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		qid, err := h.Attach(ctx, fid, afid, m.Uname(), m.Aname())
+		if err != nil {
+			c.fids.Del(m.Fid())
+			return err
+		}
+		fid.attached(qid, m.Uname())
+		c.fids.Publish(fid)
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rattach(tag, qid) })
+
+	case *proto.FlushReq:
+		c.flush(ctx, tag, m.Oldtag())
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rflush(tag) })
+
+	case *proto.WalkReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		if err := fid.checkWalk(m.Wname()); err != nil {
+			return err
+		}
+		newfid := fid
+		if m.Newfid() != m.Fid() {
+			if newfid, err = c.fids.Add(m.Newfid()); err != nil {
+				return err
+			}
+			defer c.fids.Put(newfid)
+		}
+		qids, err := h.Walk(ctx, fid, newfid, m.Wname())
+		if err == nil && len(qids) == 0 && len(m.Wname()) > 0 {
+			err = errNotFound
+		}
+		if (err != nil || len(qids) < len(m.Wname())) && newfid != fid {
+			c.fids.Del(m.Newfid())
+		}
+		if err != nil {
+			return err
+		}
+		if len(qids) == len(m.Wname()) {
+			qid := fid.QID()
+			if len(qids) > 0 {
+				qid = qids[len(qids)-1]
+			}
+			fid.walked(newfid, qid)
+			c.fids.Publish(newfid)
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rwalk(tag, qids) })
+
+	case *proto.OpenReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		if err := fid.checkOpen(m.Mode()); err != nil {
+			return err
+		}
+		qid, iounit, err := h.Open(ctx, fid, m.Mode())
+		if err != nil {
+			return err
+		}
+		fid.opened(qid, m.Mode())
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Ropen(tag, qid, iounit) })
+
+	case *proto.CreateReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		if err := fid.checkCreate(); err != nil {
+			return err
+		}
+		qid, iounit, err := h.Create(ctx, fid, m.Name(), plan9.Perm(m.Perm()), m.Mode())
+		if err != nil {
+			return err
+		}
+		fid.opened(qid, m.Mode())
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rcreate(tag, qid, iounit) })
+
+	case *proto.ReadReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		if err := fid.checkRead(m.Offset()); err != nil {
+			return err
+		}
+		var max uint32
+		if c.session.Msize > plan9.IOHDRSZ {
+			max = c.session.Msize - plan9.IOHDRSZ
+		}
+		count := m.Count()
+		if count > max {
+			count = max
+		}
+		p := make([]byte, count)
+		n, err := h.Read(ctx, fid, m.Offset(), p)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		fid.read(m.Offset(), n)
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rread(tag, p[:n]) })
+
+	case *proto.WriteReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		if err := fid.checkWrite(); err != nil {
+			return err
+		}
+		n, err := h.Write(ctx, fid, m.Offset(), m.Data())
+		if err != nil {
+			return err
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rwrite(tag, uint32(n)) })
+
+	case *proto.ClunkReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		if err := c.fids.Release(fid); err != nil {
+			return err
+		}
+		if err := h.Clunk(ctx, fid); err != nil {
+			return err
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rclunk(tag) })
+
+	case *proto.RemoveReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		if err := c.fids.Release(fid); err != nil {
+			return err
+		}
+		if err := h.Remove(ctx, fid); err != nil {
+			return err
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rremove(tag) })
+
+	case *proto.StatReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		d, err := h.Stat(ctx, fid)
+		if err != nil {
+			return err
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rstat(tag, d) })
+
+	case *proto.WstatReq:
+		fid, err := c.fids.Get(m.Fid())
+		if err != nil {
+			return err
+		}
+		defer c.fids.Put(fid)
+		if err := h.Wstat(ctx, fid, m.Stat()); err != nil {
+			return err
+		}
+		c.reply(r, func(enc *proto.Encoder) error { return enc.Rwstat(tag) })
+
+	default:
+		return errBadMsg
+	}
+	return nil
+}
@@ -0,0 +1,453 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+	"plan9.io"
+	"plan9.io/client"
+	proto "plan9.io/encoding/plan9"
+)
+
+// flatFS is a directory of files, without subdirectories. The Aux of a
+// fid is the name of its file, "" for the directory.
+type flatFS struct {
+	mu     sync.Mutex
+	files  map[string][]byte
+	clunks int
+}
+
+var errNotExist = errors.New("file does not exist")
+
+func (fs *flatFS) qid(name string) plan9.QID {
+	if name == "" {
+		return plan9.QID{Type: plan9.QTDIR}
+	}
+	return plan9.QID{Path: uint64(len(name))}
+}
+
+func (fs *flatFS) Attach(ctx context.Context, fid, afid *Fid, uname, aname string) (plan9.QID, error) {
+	fid.Aux = ""
+	return fs.qid(""), nil
+}
+
+func (fs *flatFS) Walk(ctx context.Context, fid, newfid *Fid, names []string) ([]plan9.QID, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	name := fid.Aux.(string)
+	var qids []plan9.QID
+	for _, n := range names {
+		if _, ok := fs.files[n]; !ok || name != "" {
+			break
+		}
+		name = n
+		qids = append(qids, fs.qid(name))
+	}
+	if len(qids) == 0 && len(names) > 0 {
+		return nil, errNotExist
+	}
+	newfid.Aux = name
+	return qids, nil
+}
+
+func (fs *flatFS) Open(ctx context.Context, fid *Fid, mode uint8) (plan9.QID, uint32, error) {
+	return fs.qid(fid.Aux.(string)), 0, nil
+}
+
+func (fs *flatFS) Create(ctx context.Context, fid *Fid, name string, perm plan9.Perm, mode uint8) (plan9.QID, uint32, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.files[name] = nil
+	fid.Aux = name
+	return fs.qid(name), 0, nil
+}
+
+func (fs *flatFS) Read(ctx context.Context, fid *Fid, offset uint64, p []byte) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	b := fs.files[fid.Aux.(string)]
+	if offset > uint64(len(b)) {
+		return 0, nil
+	}
+	return copy(p, b[offset:]), nil
+}
+
+func (fs *flatFS) Write(ctx context.Context, fid *Fid, offset uint64, data []byte) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	name := fid.Aux.(string)
+	b := fs.files[name]
+	for uint64(len(b)) < offset+uint64(len(data)) {
+		b = append(b, 0)
+	}
+	copy(b[offset:], data)
+	fs.files[name] = b
+	return len(data), nil
+}
+
+func (fs *flatFS) Clunk(ctx context.Context, fid *Fid) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.clunks++
+	return nil
+}
+
+func (fs *flatFS) Remove(ctx context.Context, fid *Fid) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	delete(fs.files, fid.Aux.(string))
+	return nil
+}
+
+func (fs *flatFS) Stat(ctx context.Context, fid *Fid) (*plan9.Dir, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	name := fid.Aux.(string)
+	return &plan9.Dir{Name: name, QID: fs.qid(name), Length: uint64(len(fs.files[name]))}, nil
+}
+
+func (fs *flatFS) Wstat(ctx context.Context, fid *Fid, d *plan9.Dir) error {
+	return errors.New("wstat prohibited")
+}
+
+// testConn serves h on one end of a pipe and returns a client for the other.
+func testConn(t *testing.T, h Handler) *client.Conn {
+	c, s := net.Pipe()
+	go (&Server{Handler: h, Msize: 8192}).ServeConn(s)
+	conn, err := client.NewConn(context.Background(), c, plan9.MSize, plan9.DefaultVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	fs := &flatFS{files: map[string][]byte{"hello": []byte("hello, world")}}
+	conn := testConn(t, fs)
+	defer conn.Close()
+	assert.Equal(t, conn.Session().Msize, uint32(8192))
+
+	root, err := conn.Root(ctx, "glenda", "")
+	assert.NilError(t, err)
+	f, err := root.Walk(ctx, "hello")
+	assert.NilError(t, err)
+	assert.NilError(t, f.Open(ctx, plan9.OREAD))
+	b, err := ioutil.ReadAll(f)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "hello, world")
+	assert.NilError(t, f.Close())
+
+	_, err = root.Walk(ctx, "nope")
+	assert.DeepEqual(t, err, &client.Error{Ename: "file does not exist"})
+
+	f, err = root.Walk(ctx)
+	assert.NilError(t, err)
+	assert.NilError(t, f.Create(ctx, "new", 0644, plan9.OWRITE))
+	_, err = f.Write([]byte("data"))
+	assert.NilError(t, err)
+	d, err := f.Stat(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, d.Length, uint64(4))
+	assert.DeepEqual(t, f.Wstat(ctx, d), &client.Error{Ename: "wstat prohibited"})
+	assert.NilError(t, f.Remove(ctx))
+	assert.Equal(t, len(fs.files), 1)
+
+	_, err = conn.Auth(ctx, 10, "glenda", "")
+	assert.DeepEqual(t, err, &client.Error{Ename: "authentication not required"})
+}
+
+func TestServerFids(t *testing.T) {
+	ctx := context.Background()
+	fs := &flatFS{files: map[string][]byte{"hello": nil}}
+	conn := testConn(t, fs)
+	defer conn.Close()
+
+	_, err := conn.Walk(ctx, 1, 2)
+	assert.DeepEqual(t, err, &client.Error{Ename: "unknown fid"})
+	_, err = conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.NilError(t, err)
+	_, err = conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.DeepEqual(t, err, &client.Error{Ename: "duplicate fid"})
+	_, err = conn.Walk(ctx, 1, 1)
+	assert.NilError(t, err)
+
+	// a failed walk leaves newfid unused
+	_, err = conn.Walk(ctx, 1, 2, "nope")
+	assert.DeepEqual(t, err, &client.Error{Ename: "file does not exist"})
+	_, err = conn.Walk(ctx, 1, 2, "hello")
+	assert.NilError(t, err)
+	_, err = conn.Walk(ctx, 1, 2)
+	assert.DeepEqual(t, err, &client.Error{Ename: "duplicate fid"})
+
+	assert.NilError(t, conn.Clunk(ctx, 2))
+	assert.DeepEqual(t, conn.Clunk(ctx, 2), &client.Error{Ename: "unknown fid"})
+}
+
+// looseFS answers walks it cannot start with no qids rather than an error,
+// and stats with names too long for the msize.
+type looseFS struct{ flatFS }
+
+func (fs *looseFS) Walk(ctx context.Context, fid, newfid *Fid, names []string) ([]plan9.QID, error) {
+	return nil, nil
+}
+
+func (fs *looseFS) Stat(ctx context.Context, fid *Fid) (*plan9.Dir, error) {
+	return &plan9.Dir{Name: strings.Repeat("x", 10000)}, nil
+}
+
+func TestServerErrors(t *testing.T) {
+	ctx := context.Background()
+	conn := testConn(t, &looseFS{})
+	defer conn.Close()
+	_, err := conn.Attach(ctx, 1, plan9.NoFID, "glenda", "")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name string
+		do   func() error
+		want string
+	}{
+		{"first name not walked", func() error {
+			_, err := conn.Walk(ctx, 1, 2, "nope")
+			return err
+		}, "file does not exist"},
+		{"too many names", func() error {
+			_, err := conn.Walk(ctx, 1, 2, strings.Split(strings.Repeat("a/", 16)+"a", "/")...)
+			return err
+		}, "too many names in walk"},
+		{"rstat too large", func() error {
+			_, err := conn.Stat(ctx, 1)
+			return err
+		}, proto.ErrMsgTooLarge.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, tt.do(), &client.Error{Ename: tt.want})
+		})
+	}
+	// the connection survives
+	assert.NilError(t, conn.Clunk(ctx, 1))
+}
+
+func TestServerVersion(t *testing.T) {
+	c, s := net.Pipe()
+	fs := &flatFS{files: map[string][]byte{}}
+	done := make(chan error)
+	go func() { done <- (&Server{Handler: fs}).ServeConn(s) }()
+
+	enc, dec := proto.NewEncoder(c), proto.NewDecoder(c)
+	_, err := proto.Version(enc, dec, 8192, "9P2000")
+	assert.NilError(t, err)
+	enc.Tattach(1, 1, plan9.NoFID, "glenda", "", plan9.NoUID)
+	enc.Flush()
+	_, err = dec.Decode()
+	assert.NilError(t, err)
+
+	// a new session clunks the fids of the old one
+	_, err = proto.Version(enc, dec, 8192, "9P2000.foo")
+	assert.NilError(t, err)
+	assert.Equal(t, fs.clunks, 1)
+	_, err = proto.Version(enc, dec, 8192, "9P3000")
+	if err == nil {
+		t.Fatal("Version succeeded with an unknown version")
+	}
+	_, err = proto.Version(enc, dec, plan9.IOHDRSZ-1, "9P2000")
+	if err == nil {
+		t.Fatal("Version succeeded with an msize below IOHDRSZ")
+	}
+
+	// only Tversion may follow an unknown version
+	enc.Tattach(1, 1, plan9.NoFID, "glenda", "", plan9.NoUID)
+	enc.Flush()
+	assert.Equal(t, <-done, errNoVersion)
+}
+
+// blockFS is a flatFS whose reads block until they are flushed.
+type blockFS struct {
+	flatFS
+}
+
+func (fs *blockFS) Read(ctx context.Context, fid *Fid, offset uint64, p []byte) (int, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestServerFlush(t *testing.T) {
+	c, s := net.Pipe()
+	fs := &blockFS{flatFS{files: map[string][]byte{"hello": nil}}}
+	go (&Server{Handler: fs}).ServeConn(s)
+	defer c.Close()
+
+	enc, dec := proto.NewEncoder(c), proto.NewDecoder(c)
+	_, err := proto.Version(enc, dec, 8192, "9P2000")
+	assert.NilError(t, err)
+	rpc := func(write func() error) proto.Message {
+		assert.NilError(t, write())
+		assert.NilError(t, enc.Flush())
+		msg, err := dec.Decode()
+		assert.NilError(t, err)
+		return msg
+	}
+	rpc(func() error { return enc.Tattach(1, 1, plan9.NoFID, "glenda", "", plan9.NoUID) })
+	rpc(func() error { return enc.Topen(1, 1, plan9.OREAD) })
+
+	tests := []struct {
+		name string
+		msg  func() error
+		want plan9.MessageType
+	}{
+		{"duplicate tag", func() error { return enc.Tread(5, 1, 0, 10) }, plan9.Rerror},
+		{"flush", func() error { return enc.Tflush(6, 5) }, plan9.Rflush},
+		{"flush of no request", func() error { return enc.Tflush(6, 5) }, plan9.Rflush},
+		{"flush of itself", func() error { return enc.Tflush(6, 6) }, plan9.Rflush},
+	}
+	// the flushed read is abandoned, so each message gets the only reply
+	assert.NilError(t, enc.Tread(5, 1, 0, 10))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := rpc(tt.msg)
+			assert.Equal(t, msg.Header().Type(), tt.want)
+		})
+	}
+
+	// the tag of the flushed request can be reused
+	msg := rpc(func() error { return enc.Tclunk(5, 1) })
+	assert.Equal(t, msg.Header().Type(), plan9.Rclunk)
+}
+
+func TestServerClunkInFlight(t *testing.T) {
+	c, s := net.Pipe()
+	fs := &blockFS{flatFS{files: map[string][]byte{"hello": nil}}}
+	go (&Server{Handler: fs}).ServeConn(s)
+	defer c.Close()
+
+	enc, dec := proto.NewEncoder(c), proto.NewDecoder(c)
+	_, err := proto.Version(enc, dec, 8192, "9P2000")
+	assert.NilError(t, err)
+	for _, write := range []func() error{
+		func() error { return enc.Tattach(1, 1, plan9.NoFID, "glenda", "", plan9.NoUID) },
+		func() error { return enc.Topen(1, 1, plan9.OREAD) },
+	} {
+		assert.NilError(t, write())
+		assert.NilError(t, enc.Flush())
+		_, err := dec.Decode()
+		assert.NilError(t, err)
+	}
+
+	// the clunk waits for the read blocked on its fid
+	assert.NilError(t, enc.Tread(5, 1, 0, 10))
+	assert.NilError(t, enc.Tclunk(6, 1))
+	assert.NilError(t, enc.Flush())
+	time.Sleep(10 * time.Millisecond)
+	fs.mu.Lock()
+	clunks := fs.clunks
+	fs.mu.Unlock()
+	assert.Equal(t, clunks, 0)
+
+	assert.NilError(t, enc.Tflush(7, 5))
+	assert.NilError(t, enc.Flush())
+	var got []plan9.MessageType
+	for i := 0; i < 2; i++ {
+		msg, err := dec.Decode()
+		assert.NilError(t, err)
+		got = append(got, msg.Header().Type())
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	assert.DeepEqual(t, got, []plan9.MessageType{plan9.Rflush, plan9.Rclunk})
+	assert.Equal(t, fs.clunks, 1)
+}
+
+// slowFS is a flatFS whose walks wait for a send on walk.
+type slowFS struct {
+	flatFS
+	walk chan struct{}
+}
+
+func (fs *slowFS) Walk(ctx context.Context, fid, newfid *Fid, names []string) ([]plan9.QID, error) {
+	<-fs.walk
+	return fs.flatFS.Walk(ctx, fid, newfid, names)
+}
+
+func TestServerPipelinedWalk(t *testing.T) {
+	c, s := net.Pipe()
+	fs := &slowFS{flatFS{files: map[string][]byte{"hello": nil}}, make(chan struct{})}
+	go (&Server{Handler: fs}).ServeConn(s)
+	defer c.Close()
+
+	enc, dec := proto.NewEncoder(c), proto.NewDecoder(c)
+	_, err := proto.Version(enc, dec, 8192, "9P2000")
+	assert.NilError(t, err)
+	assert.NilError(t, enc.Tattach(1, 1, plan9.NoFID, "glenda", "", plan9.NoUID))
+	assert.NilError(t, enc.Flush())
+	_, err = dec.Decode()
+	assert.NilError(t, err)
+
+	// the newfid of a walk in progress is not yet known
+	assert.NilError(t, enc.Twalk(2, 1, 2, []string{"hello"}))
+	assert.NilError(t, enc.Tstat(3, 2))
+	assert.NilError(t, enc.Flush())
+	msg, err := dec.Decode()
+	assert.NilError(t, err)
+	r, ok := msg.(*proto.ErrorResp)
+	if !ok {
+		t.Fatalf("got %v, want Rerror", msg.Header().Type())
+	}
+	assert.Equal(t, r.Ename(), ErrUnknownFid.Error())
+
+	// but it is reserved
+	assert.NilError(t, enc.Twalk(4, 1, 2, nil))
+	assert.NilError(t, enc.Flush())
+	msg, err = dec.Decode()
+	assert.NilError(t, err)
+	r, ok = msg.(*proto.ErrorResp)
+	if !ok {
+		t.Fatalf("got %v, want Rerror", msg.Header().Type())
+	}
+	assert.Equal(t, r.Ename(), ErrDupFid.Error())
+
+	fs.walk <- struct{}{}
+	msg, err = dec.Decode()
+	assert.NilError(t, err)
+	assert.Equal(t, msg.Header().Type(), plan9.Rwalk)
+	assert.NilError(t, enc.Tstat(3, 2))
+	assert.NilError(t, enc.Flush())
+	msg, err = dec.Decode()
+	assert.NilError(t, err)
+	assert.Equal(t, msg.Header().Type(), plan9.Rstat)
+}
+
+func TestServerCrossedFlushes(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		c, s := net.Pipe()
+		done := make(chan error)
+		go func() { done <- (&Server{Handler: &flatFS{}}).ServeConn(s) }()
+
+		enc, dec := proto.NewEncoder(c), proto.NewDecoder(c)
+		_, err := proto.Version(enc, dec, 8192, "9P2000")
+		assert.NilError(t, err)
+		// two Tflushes flushing each other are both answered
+		assert.NilError(t, enc.Tflush(1, 2))
+		assert.NilError(t, enc.Tflush(2, 1))
+		assert.NilError(t, enc.Flush())
+		for j := 0; j < 2; j++ {
+			msg, err := dec.Decode()
+			assert.NilError(t, err)
+			assert.Equal(t, msg.Header().Type(), plan9.Rflush)
+		}
+		c.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("ServeConn still running after the client hung up")
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package plan9
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionUnknown is the version a server answers with when it
+// understands none of the client's version string.
+const VersionUnknown = "unknown"
+
+// MinMsize is the smallest msize Negotiate and Accept agree on. It leaves
+// room for a little data after the IOHDRSZ of reads and writes, and for
+// the short messages that carry no data.
+const MinMsize = 256
+
+// Session holds the parameters of a connection agreed on with Tversion.
+// A Tversion starts a new session, aborting all outstanding I/O and
+// clunking every fid of the previous one.
+type Session struct {
+	Msize   uint32  // maximum size of any message, including the header
+	Version string  // the protocol version, VersionUnknown if none was agreed on
+	Dialect Dialect // the dialect named by Version
+}
+
+// DefaultSession is the session in effect before Tversion.
+var DefaultSession = Session{Msize: MSize, Version: DefaultVersion, Dialect: Dialect9P2000}
+
+// Negotiate implements the server side of version(5). Given the msize and
+// version of a Tversion, the largest msize the server accepts and the
+// dialects it speaks, it returns the session to answer with in Rversion.
+// If no dialects are given the server speaks 9P2000 only.
+//
+// The msize is the smaller of the two, and an error if that is less than
+// MinMsize. A version the server does not understand is truncated at its
+// first period, so 9P2000.foo is answered with 9P2000, and if that isn't
+// understood either the version is VersionUnknown.
+func Negotiate(msize uint32, version string, maxMsize uint32, dialects ...Dialect) (Session, error) {
+	if len(dialects) == 0 {
+		dialects = []Dialect{Dialect9P2000}
+	}
+	if msize > maxMsize {
+		msize = maxMsize
+	}
+	if msize < MinMsize {
+		return Session{}, fmt.Errorf("9p: msize %d smaller than %d", msize, MinMsize)
+	}
+	s := Session{Msize: msize, Version: VersionUnknown}
+	if !strings.HasPrefix(version, "9P") {
+		return s, nil
+	}
+	for _, v := range []string{version, strings.SplitN(version, ".", 2)[0]} {
+		d, err := ParseDialect(v)
+		if err != nil {
+			continue
+		}
+		for _, supported := range dialects {
+			if d == supported {
+				s.Version, s.Dialect = v, d
+				return s, nil
+			}
+		}
+	}
+	return s, nil
+}
+
+// Accept implements the client side of version(5). Given the msize and
+// version a client sent in Tversion and the ones the server answered with
+// in Rversion, it returns the agreed session, or an error if the server
+// answered with a version or msize the client did not offer, or an msize
+// less than MinMsize.
+func Accept(msize uint32, version string, rmsize uint32, rversion string) (Session, error) {
+	if rversion == VersionUnknown {
+		return Session{}, fmt.Errorf("9p: server does not understand version %q", version)
+	}
+	if rversion != version && rversion != strings.SplitN(version, ".", 2)[0] {
+		return Session{}, fmt.Errorf("9p: server answered version %q to %q", rversion, version)
+	}
+	if rmsize > msize {
+		return Session{}, fmt.Errorf("9p: server msize %d larger than %d", rmsize, msize)
+	}
+	if rmsize < MinMsize {
+		return Session{}, fmt.Errorf("9p: server msize %d smaller than %d", rmsize, MinMsize)
+	}
+	d, err := ParseDialect(rversion)
+	if err != nil {
+		return Session{}, err
+	}
+	return Session{Msize: rmsize, Version: rversion, Dialect: d}, nil
+}
@@ -0,0 +1,69 @@
+package plan9
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		msize    uint32
+		version  string
+		dialects []Dialect
+		want     Session
+		wantErr  bool
+	}{
+		{"9P2000", 8192, "9P2000", nil, Session{8192, "9P2000", Dialect9P2000}, false},
+		{"msize", MSize * 2, "9P2000", nil, Session{MSize, "9P2000", Dialect9P2000}, false},
+		{"suffix", 8192, "9P2000.foo", nil, Session{8192, "9P2000", Dialect9P2000}, false},
+		{"unsupported dialect", 8192, "9P2000.u", nil, Session{8192, "9P2000", Dialect9P2000}, false},
+		{"dialect", 8192, "9P2000.u", []Dialect{Dialect9P2000, Dialect9P2000u}, Session{8192, "9P2000.u", Dialect9P2000u}, false},
+		{"9P2000.L", 8192, "9P2000.L", []Dialect{Dialect9P2000L}, Session{8192, "9P2000.L", Dialect9P2000L}, false},
+		{"only 9P2000.L", 8192, "9P2000", []Dialect{Dialect9P2000L}, Session{8192, "unknown", 0}, false},
+		{"unknown", 8192, "9P3000", nil, Session{8192, "unknown", 0}, false},
+		{"not 9P", 8192, "styx", nil, Session{8192, "unknown", 0}, false},
+		{"smallest msize", MinMsize, "9P2000", nil, Session{MinMsize, "9P2000", Dialect9P2000}, false},
+		{"msize too small", MinMsize - 1, "9P2000", nil, Session{}, true},
+		{"msize below IOHDRSZ", IOHDRSZ - 1, "9P2000", nil, Session{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Negotiate(tt.msize, tt.version, MSize, tt.dialects...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Negotiate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestAccept(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		rmsize   uint32
+		rversion string
+		want     Session
+		wantErr  bool
+	}{
+		{"9P2000", "9P2000", 8192, "9P2000", Session{8192, "9P2000", Dialect9P2000}, false},
+		{"smaller msize", "9P2000", 4096, "9P2000", Session{4096, "9P2000", Dialect9P2000}, false},
+		{"larger msize", "9P2000", 16384, "9P2000", Session{}, true},
+		{"suffix", "9P2000.u", 8192, "9P2000", Session{8192, "9P2000", Dialect9P2000}, false},
+		{"dialect", "9P2000.u", 8192, "9P2000.u", Session{8192, "9P2000.u", Dialect9P2000u}, false},
+		{"other dialect", "9P2000.u", 8192, "9P2000.L", Session{}, true},
+		{"unknown", "9P2000", 8192, "unknown", Session{}, true},
+		{"msize too small", "9P2000", MinMsize - 1, "9P2000", Session{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Accept(8192, tt.version, tt.rmsize, tt.rversion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Accept() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}